@@ -2,36 +2,208 @@ package main
 
 import (
 	"errors"
+	"flag"
+	"fmt"
 	"github.com/peeta98/blog-aggregator/internal/database"
+	"sort"
+	"strings"
+	"time"
 )
 
 type command struct {
 	Name string
 	Args []string
+	// Flags holds the parsed values of the flags declared by the command spec.
+	Flags *flag.FlagSet
 }
 
 type commandHandler func(*state, command) error
 
 type authenticatedCommandHandler func(*state, command, database.User) error
 
+// commandSpec describes a command: what the help output shows about it and
+// what the arguments must look like before its handler is called.
+type commandSpec struct {
+	Name    string
+	Summary string
+	// Usage lists the positional arguments, e.g. "<feed_url> [name]".
+	Usage   string
+	MinArgs int
+	// MaxArgs is the maximum number of positional arguments, or -1 for no limit.
+	MaxArgs int
+	// Flags declares the command's flags on the given flag set.
+	Flags         func(fs *flag.FlagSet)
+	LoginRequired bool
+	// SkipSchemaCheck lets the command run on a database that is missing
+	// migrations.
+	SkipSchemaCheck bool
+}
+
+type registeredCommand struct {
+	spec    commandSpec
+	handler commandHandler
+}
+
 type commands struct {
-	registeredCommands map[string]commandHandler
+	registeredCommands map[string]registeredCommand
+}
+
+func (c *commands) register(spec commandSpec, handler commandHandler) {
+	c.registeredCommands[spec.Name] = registeredCommand{
+		spec:    spec,
+		handler: handler,
+	}
 }
 
-func (c *commands) register(name string, handler commandHandler) {
-	c.registeredCommands[name] = handler
+// registerLoggedIn registers a command that needs a logged in user.
+func (c *commands) registerLoggedIn(spec commandSpec, handler authenticatedCommandHandler) {
+	spec.LoginRequired = true
+	c.register(spec, middlewareLoggedIn(handler))
 }
 
 func (c *commands) run(s *state, cmd command) error {
-	handler, ok := c.registeredCommands[cmd.Name]
+	registered, ok := c.registeredCommands[cmd.Name]
 	if !ok {
-		return errors.New("command not found")
+		return c.unknownCommand(cmd.Name)
+	}
+	spec := registered.spec
+
+	fs := spec.flagSet()
+	args, err := parseFlags(fs, cmd.Args)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandHelp(spec)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, spec.usage())
+	}
+	if err := validateOutputFormat(fs.Lookup("output").Value.String()); err != nil {
+		return err
+	}
+	if len(args) < spec.MinArgs || (spec.MaxArgs >= 0 && len(args) > spec.MaxArgs) {
+		return errors.New(spec.usage())
+	}
+
+	if !spec.SkipSchemaCheck {
+		if err := checkSchemaVersion(s); err != nil {
+			return err
+		}
+	}
+
+	cmd.Args = args
+	cmd.Flags = fs
+	return registered.handler(s, cmd)
+}
+
+func (c *commands) unknownCommand(name string) error {
+	if suggestion := c.closest(name); suggestion != "" {
+		return fmt.Errorf("unknown command %q, did you mean %q?", name, suggestion)
+	}
+	return fmt.Errorf("unknown command %q, run 'help' to list the commands", name)
+}
+
+// closest returns the registered command nearest to name, or "" when none is
+// close enough to be a likely typo.
+func (c *commands) closest(name string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range c.names() {
+		distance := editDistance(name, candidate)
+		if strings.HasPrefix(candidate, name) && len(name) >= 3 {
+			distance = 1
+		}
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func (c *commands) names() []string {
+	names := make([]string, 0, len(c.registeredCommands))
+	for name := range c.registeredCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagSet returns the flags accepted by the command: its own and the
+// global ones.
+func (spec commandSpec) flagSet() *flag.FlagSet {
+	fs := spec.commandFlagSet()
+	globalFlags(fs)
+	return fs
+}
+
+func (spec commandSpec) commandFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.Name, flag.ContinueOnError)
+	if spec.Flags != nil {
+		spec.Flags(fs)
+	}
+	return fs
+}
+
+// globalFlags declares the flags every command accepts.
+func globalFlags(fs *flag.FlagSet) {
+	fs.String("output", outputText, "output `format` of listings: text, json, ndjson, csv or table")
+}
+
+func (spec commandSpec) usage() string {
+	usage := "usage: " + spec.Name
+	if spec.Usage != "" {
+		usage += " " + spec.Usage
+	}
+	if spec.hasFlags() {
+		usage += " [flags]"
+	}
+	return usage
+}
+
+func (spec commandSpec) hasFlags() bool {
+	hasFlags := false
+	spec.commandFlagSet().VisitAll(func(*flag.Flag) { hasFlags = true })
+	return hasFlags
+}
+
+func (cmd command) stringFlag(name string) string {
+	return cmd.Flags.Lookup(name).Value.(flag.Getter).Get().(string)
+}
+
+func (cmd command) boolFlag(name string) bool {
+	return cmd.Flags.Lookup(name).Value.(flag.Getter).Get().(bool)
+}
+
+func (cmd command) intFlag(name string) int {
+	return cmd.Flags.Lookup(name).Value.(flag.Getter).Get().(int)
+}
+
+func (cmd command) durationFlag(name string) time.Duration {
+	return cmd.Flags.Lookup(name).Value.(flag.Getter).Get().(time.Duration)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
 	}
-	return handler(s, cmd)
+	return prev[len(b)]
 }
 
 func newCommands() *commands {
 	return &commands{
-		registeredCommands: make(map[string]commandHandler),
+		registeredCommands: make(map[string]registeredCommand),
 	}
 }
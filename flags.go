@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+)
+
+// parseFlags parses the flags defined on fs from args, allowing them to
+// appear before, after or between positional arguments, and returns the
+// positional arguments in order. Everything after a "--" is positional, so
+// that arguments starting with a dash can be passed.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	args, rest := splitTerminator(fs, args)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// splitTerminator splits args around the "--" ending the flags, if any. A
+// "--" given as the value of a flag, as in --feed --, doesn't count.
+func splitTerminator(fs *flag.FlagSet, args []string) (flags, rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+		if len(arg) < 2 || arg[0] != '-' || strings.Contains(arg, "=") {
+			continue
+		}
+		f := fs.Lookup(strings.TrimLeft(arg, "-"))
+		if f == nil {
+			continue
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		// The next argument is the flag's value.
+		i++
+	}
+	return args, nil
+}
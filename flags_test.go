@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args       []string
+		feed       string
+		all        bool
+		positional []string
+	}{
+		{[]string{"10"}, "", false, []string{"10"}},
+		{[]string{"--feed", "Go", "10", "--all"}, "Go", true, []string{"10"}},
+		{[]string{"10", "--all", "20", "--feed=Go"}, "Go", true, []string{"10", "20"}},
+		{[]string{"--all", "--", "--feed", "10"}, "", true, []string{"--feed", "10"}},
+		{[]string{"10", "--", "-5"}, "", false, []string{"10", "-5"}},
+		// A "--" that is a flag's value doesn't end the flags.
+		{[]string{"--feed", "--", "10", "--all"}, "--", true, []string{"10"}},
+		{[]string{"-feed", "--", "--", "--all"}, "--", false, []string{"--all"}},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+		feed := fs.String("feed", "", "")
+		all := fs.Bool("all", false, "")
+		positional, err := parseFlags(fs, tt.args)
+		if err != nil {
+			t.Errorf("parseFlags(%q) returned error: %v", tt.args, err)
+			continue
+		}
+		if *feed != tt.feed || *all != tt.all || !reflect.DeepEqual(positional, tt.positional) {
+			t.Errorf("parseFlags(%q) = --feed %q --all=%t %q, want --feed %q --all=%t %q", tt.args, *feed, *all, positional, tt.feed, tt.all, tt.positional)
+		}
+	}
+}
@@ -3,83 +3,500 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/feed"
+	"github.com/peeta98/blog-aggregator/internal/metrics"
+	"github.com/peeta98/blog-aggregator/internal/schedule"
 	"log"
-	"strings"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
+type aggregateOptions struct {
+	concurrency int
+	maxFailures int
+	limiter     *hostLimiter
+	// owner identifies this aggregator in the leases it takes on the feeds
+	// it fetches, so that concurrent aggregators share feeds out instead of
+	// fetching them twice.
+	owner string
+	lease time.Duration
+	// stopping is closed when the aggregator shuts down.
+	stopping <-chan struct{}
+}
+
+// drainTimeout is how long a shutdown waits for the fetches or requests in
+// flight to finish before cancelling them.
+const drainTimeout = 30 * time.Second
+
+func aggregateFlags(fs *flag.FlagSet) {
+	fs.Int("concurrency", 1, "number of feeds fetched in parallel")
+	fs.Int("per-host", 1, "maximum concurrent requests to a single host")
+	fs.Duration("host-delay", time.Second, "minimum delay between requests to a single host")
+	fs.Int("max-failures", 10, "consecutive failures after which a feed is disabled")
+	fs.Duration("lease", 5*time.Minute, "how long other aggregators leave a feed to this one once it claimed it")
+	fs.Bool("once", false, "collect every enabled feed once, due or not, and exit")
+	fs.String("feed", "", "collect only the feed at `url` once and exit")
+	fs.String("metrics-addr", "", "serve Prometheus metrics on `address`, e.g. :9090")
+}
+
 func handlerAggregate(s *state, cmd command) error {
-	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
-		return fmt.Errorf("usage: %v <time_between_reqs>", cmd.Name)
+	concurrency := cmd.intFlag("concurrency")
+	perHost := cmd.intFlag("per-host")
+	maxFailures := cmd.intFlag("max-failures")
+	if concurrency < 1 || perHost < 1 || maxFailures < 1 {
+		return errors.New("concurrency, per-host and max-failures must be positive numbers")
+	}
+	lease := cmd.durationFlag("lease")
+	if lease < time.Second {
+		return errors.New("lease must be at least a second")
 	}
 
+	// ctx ends on the first interrupt and stops new fetches from starting,
+	// fetchCtx lets the ones in flight finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fetchCtx, cancelFetches := drainContext(ctx, "fetches")
+	defer cancelFetches()
+
+	opts := aggregateOptions{
+		concurrency: concurrency,
+		maxFailures: maxFailures,
+		limiter:     newHostLimiter(perHost, cmd.durationFlag("host-delay")),
+		owner:       aggregatorID(),
+		lease:       lease,
+		stopping:    ctx.Done(),
+	}
+
+	if addr := cmd.stringFlag("metrics-addr"); addr != "" {
+		stopMetrics, err := serveMetrics(s, addr)
+		if err != nil {
+			return err
+		}
+		defer stopMetrics()
+	}
+
+	if feedURL := cmd.stringFlag("feed"); feedURL != "" {
+		dbFeed, err := s.db.GetFeedByUrl(ctx, feedURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("feed %s not found", feedURL)
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't get feed: %w", err)
+		}
+		if dbFeed.DisabledAt.Valid {
+			return fmt.Errorf("feed %s is disabled, run 'blog-aggregator enablefeed %s' to collect it again", feedURL, feedURL)
+		}
+		return refreshFeeds(fetchCtx, s, []database.Feed{dbFeed}, opts)
+	}
+
+	if cmd.boolFlag("once") {
+		feeds, err := s.db.GetFeeds(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't get feeds: %w", err)
+		}
+		enabled := make([]database.Feed, 0, len(feeds))
+		for _, dbFeed := range feeds {
+			if !dbFeed.DisabledAt.Valid {
+				enabled = append(enabled, dbFeed)
+			}
+		}
+		return refreshFeeds(fetchCtx, s, enabled, opts)
+	}
+
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf("usage: %s <time_between_reqs> | --once | --feed <url>", cmd.Name)
+	}
 	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
 	if err != nil {
 		return fmt.Errorf("invalid duration: %w", err)
 	}
 
-	log.Printf("Collecting feeds every %s...", timeBetweenRequests)
+	log.Printf("Collecting up to %d feeds every %s...", opts.concurrency, timeBetweenRequests)
 
 	ticker := time.NewTicker(timeBetweenRequests)
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		scrapeFeeds(fetchCtx, s, opts)
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
 	}
+	return nil
+}
+
+// drainContext returns the context of the work in flight, which outlives ctx
+// by up to drainTimeout so that a shutdown doesn't interrupt a feed halfway
+// through saving its posts, or a request halfway through its response. A
+// second interrupt cancels it right away. work names it in the log.
+func drainContext(ctx context.Context, work string) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-drainCtx.Done():
+			return
+		}
+
+		interrupted, stop := signal.NotifyContext(drainCtx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		timer := time.NewTimer(drainTimeout)
+		defer timer.Stop()
+
+		log.Printf("Shutting down, letting %s in flight finish for up to %s (interrupt again to stop now)...", work, drainTimeout)
+		select {
+		case <-interrupted.Done():
+		case <-timer.C:
+		}
+		cancel()
+	}()
+	return drainCtx, cancel
 }
 
-func scrapeFeeds(s *state) {
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
+// aggregatorID returns a name for this process that is unique among the
+// aggregators sharing a database, even in containers that all run as pid 1.
+func aggregatorID() string {
+	host, err := os.Hostname()
 	if err != nil {
-		log.Println("Couldn't get next feed to fetch", err)
-		return
+		host = "unknown"
 	}
-	log.Println("Found a feed to fetch!")
-	scrapeFeed(s.db, feed)
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), uuid.NewString()[:8])
 }
 
-func scrapeFeed(db *database.Queries, feed database.Feed) {
-	_, err := db.MarkFeedFetched(context.Background(), feed.ID)
+func scrapeFeeds(ctx context.Context, s *state, opts aggregateOptions) {
+	feeds, err := s.db.GetNextFeedsToFetch(ctx, database.GetNextFeedsToFetchParams{
+		Now:          time.Now().UTC(),
+		LeaseOwner:   opts.owner,
+		LeaseSeconds: int32(opts.lease.Seconds()),
+		FeedLimit:    int32(opts.concurrency),
+	})
 	if err != nil {
-		log.Printf("Couldn't mark feed %s fetched: %v", feed.Name, err)
+		log.Println("Couldn't get next feeds to fetch", err)
 		return
 	}
-	
-	feedData, err := fetchFeed(context.Background(), feed.Url)
-	if err != nil {
-		log.Printf("Couldn't collect feed %s: %v", feed.Name, err)
-		return
+	log.Printf("Found %d feeds to fetch!", len(feeds))
+	fetchFeeds(ctx, s.db, feeds, opts, false)
+}
+
+// refreshFeeds collects the given feeds once, whether or not they are due,
+// and fails if any of them couldn't be collected. Feeds another aggregator
+// is fetching are left to it.
+func refreshFeeds(ctx context.Context, s *state, feeds []database.Feed, opts aggregateOptions) error {
+	log.Printf("Collecting %d feeds...", len(feeds))
+	if failed := fetchFeeds(ctx, s.db, feeds, opts, true); failed > 0 {
+		return fmt.Errorf("couldn't collect %d of %d feeds", failed, len(feeds))
 	}
-	for _, item := range feedData.Channel.Item {
-		publishedAt := sql.NullTime{}
-		if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
-			publishedAt = sql.NullTime{
-				Time:  t,
-				Valid: true,
+	return nil
+}
+
+// fetchFeeds collects the feeds, up to opts.concurrency at a time, and
+// returns how many of them failed. With claim, the feeds aren't leased yet
+// and each is claimed just before it's fetched, so that no lease runs out
+// while its feed waits for its turn.
+func fetchFeeds(ctx context.Context, db database.Store, feeds []database.Feed, opts aggregateOptions, claim bool) int {
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
+	running := make(chan struct{}, opts.concurrency)
+dispatch:
+	for i, dbFeed := range feeds {
+		running <- struct{}{}
+		select {
+		case <-opts.stopping:
+			// The feeds that haven't started wait for the next run
+			for _, waiting := range feeds[i:] {
+				releaseFeed(ctx, db, waiting, opts)
 			}
+			failed.Add(int32(len(feeds) - i))
+			break dispatch
+		default:
 		}
 
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now().UTC(),
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-running }()
+			release, err := opts.limiter.acquire(ctx, dbFeed.Url)
+			if err != nil {
+				log.Printf("Couldn't schedule feed %s: %v", dbFeed.Name, err)
+				releaseFeed(ctx, db, dbFeed, opts)
+				failed.Add(1)
+				return
+			}
+			defer release()
+			if claim {
+				claimed, err := claimFeed(ctx, db, dbFeed, opts)
+				if errors.Is(err, sql.ErrNoRows) {
+					log.Printf("Feed %s is being fetched by another aggregator, skipping it", dbFeed.Name)
+					return
+				}
+				if err != nil {
+					log.Printf("Couldn't claim feed %s: %v", dbFeed.Name, err)
+					failed.Add(1)
+					return
+				}
+				dbFeed = claimed
+			}
+			if err := scrapeFeed(ctx, db, dbFeed, opts); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return int(failed.Load())
+}
+
+func scrapeFeed(ctx context.Context, db database.Store, dbFeed database.Feed, opts aggregateOptions) error {
+	hints, err := collectFeed(ctx, db, dbFeed)
+	if err != nil && ctx.Err() != nil {
+		// The feed isn't at fault, it will be fetched again on the next run
+		log.Printf("Collection of feed %s interrupted", dbFeed.Name)
+		releaseFeed(ctx, db, dbFeed, opts)
+		return err
+	}
+	if err != nil {
+		log.Printf("Couldn't collect feed %s: %v", dbFeed.Name, err)
+		recordFeedFailure(ctx, db, dbFeed, err, opts.maxFailures)
+		return err
+	}
+	scheduleNextFetch(ctx, db, dbFeed, hints)
+	return nil
+}
+
+// claimFeed leases a feed to this aggregator for a fetch outside of the
+// schedule. It returns sql.ErrNoRows while another aggregator holds the
+// lease.
+func claimFeed(ctx context.Context, db database.Store, dbFeed database.Feed, opts aggregateOptions) (database.Feed, error) {
+	return db.ClaimFeed(ctx, database.ClaimFeedParams{
+		Now:          time.Now().UTC(),
+		LeaseOwner:   opts.owner,
+		LeaseSeconds: int32(opts.lease.Seconds()),
+		ID:           dbFeed.ID,
+	})
+}
+
+// releaseFeed hands a feed that wasn't collected back to the other
+// aggregators without waiting for its lease to expire. It runs even when ctx
+// was cancelled by a shutdown.
+func releaseFeed(ctx context.Context, db database.Store, dbFeed database.Feed, opts aggregateOptions) {
+	err := db.ReleaseFeedLease(context.WithoutCancel(ctx), database.ReleaseFeedLeaseParams{
+		ID:         dbFeed.ID,
+		LeaseOwner: sql.NullString{String: opts.owner, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Couldn't release feed %s: %v", dbFeed.Name, err)
+	}
+}
+
+// collectFeed fetches the feed and stores its new posts, returning what was
+// learned about how often the feed should be polled.
+func collectFeed(ctx context.Context, db database.Store, dbFeed database.Feed) (schedule.Hints, error) {
+	hints := schedule.Hints{}
+	if dbFeed.FetchIntervalSeconds.Valid {
+		hints.Configured = time.Duration(dbFeed.FetchIntervalSeconds.Int32) * time.Second
+	}
+
+	res, err := feed.Fetch(ctx, feed.Request{
+		URL:          dbFeed.Url,
+		ETag:         dbFeed.Etag.String,
+		LastModified: dbFeed.LastModified.String,
+	})
+	if err != nil {
+		return hints, err
+	}
+	hints.Expires = res.Expires
+
+	err = db.RecordFeedSuccess(ctx, database.RecordFeedSuccessParams{
+		ID:             dbFeed.ID,
+		LastStatusCode: sql.NullInt32{Int32: int32(res.StatusCode), Valid: true},
+		UpdatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Couldn't record successful fetch of feed %s: %v", dbFeed.Name, err)
+	}
+
+	if res.ETag != dbFeed.Etag.String || res.LastModified != dbFeed.LastModified.String {
+		err = db.UpdateFeedHTTPCache(ctx, database.UpdateFeedHTTPCacheParams{
+			ID:           dbFeed.ID,
+			Etag:         sql.NullString{String: res.ETag, Valid: res.ETag != ""},
+			LastModified: sql.NullString{String: res.LastModified, Valid: res.LastModified != ""},
+			UpdatedAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			log.Printf("Couldn't update cache validators of feed %s: %v", dbFeed.Name, err)
+		}
+	}
+
+	if res.NotModified {
+		log.Printf("Feed %s not modified since last fetch", dbFeed.Name)
+		return hints, nil
+	}
+
+	feedData := res.Feed
+	hints.TTL = feedData.TTL
+	if feedData.Link != dbFeed.SiteUrl.String {
+		err = db.UpdateFeedSiteURL(ctx, database.UpdateFeedSiteURLParams{
+			ID:        dbFeed.ID,
+			SiteUrl:   sql.NullString{String: feedData.Link, Valid: feedData.Link != ""},
 			UpdatedAt: time.Now().UTC(),
-			FeedID:    feed.ID,
+		})
+		if err != nil {
+			log.Printf("Couldn't update site URL of feed %s: %v", dbFeed.Name, err)
+		}
+	}
+	counts := savePosts(ctx, db, dbFeed, feedData.Items)
+	metrics.SavedPosts.WithLabelValues("created").Add(float64(counts.created))
+	metrics.SavedPosts.WithLabelValues("updated").Add(float64(counts.updated))
+	metrics.SavedPosts.WithLabelValues("unchanged").Add(float64(counts.unchanged))
+	log.Printf("Feed %s collected, %d posts found: %d new, %d updated, %d unchanged",
+		dbFeed.Name, len(feedData.Items), counts.created, counts.updated, counts.unchanged)
+	return hints, nil
+}
+
+type postCounts struct {
+	created   int
+	updated   int
+	unchanged int
+}
+
+// savePosts upserts the items of a feed, keyed by their GUID, and reports
+// how many were new, changed or already up to date.
+func savePosts(ctx context.Context, db database.Store, dbFeed database.Feed, items []feed.Item) postCounts {
+	var counts postCounts
+	for _, item := range items {
+		guid := item.ID()
+		if guid == "" {
+			log.Printf("Skipping post without GUID or link in feed %s", dbFeed.Name)
+			continue
+		}
+
+		now := time.Now().UTC()
+		// Items without a parseable date are dated when first seen, so they
+		// sort sensibly; an existing publication date is never overwritten.
+		publishedAt := sql.NullTime{
+			Time:  now,
+			Valid: true,
+		}
+		if !item.Published.IsZero() {
+			publishedAt.Time = item.Published
+		}
+
+		params := database.UpsertPostParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			FeedID:    dbFeed.ID,
 			Title:     item.Title,
 			Description: sql.NullString{
-				String: item.Description,
+				String: item.Description(),
 				Valid:  true,
 			},
 			Url:         item.Link,
 			PublishedAt: publishedAt,
+			Guid:        guid,
+			Content: sql.NullString{
+				String: item.Content,
+				Valid:  item.Content != "",
+			},
+		}
+		post, err := db.UpsertPost(ctx, params)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			counts.unchanged++
+		case err != nil:
+			log.Printf("Couldn't save post %s: %v", guid, err)
+		case post.ID == params.ID:
+			counts.created++
+		default:
+			counts.updated++
+		}
+	}
+	return counts
+}
+
+// recordFeedFailure stores the error on the feed and backs off
+// exponentially, disabling the feed once it has failed maxFailures times in
+// a row.
+func recordFeedFailure(ctx context.Context, db database.Store, dbFeed database.Feed, fetchErr error, maxFailures int) {
+	statusCode := sql.NullInt32{}
+	var retryAfter time.Duration
+	var statusErr *feed.StatusError
+	if errors.As(fetchErr, &statusErr) {
+		statusCode = sql.NullInt32{Int32: int32(statusErr.StatusCode), Valid: true}
+		retryAfter = statusErr.RetryAfter
+	}
+
+	now := time.Now().UTC()
+	updatedFeed, err := db.RecordFeedFailure(ctx, database.RecordFeedFailureParams{
+		LastError:      sql.NullString{String: fetchErr.Error(), Valid: true},
+		LastStatusCode: statusCode,
+		Now:            now,
+		ID:             dbFeed.ID,
+	})
+	if err != nil {
+		log.Printf("Couldn't record failure of feed %s: %v", dbFeed.Name, err)
+		return
+	}
+
+	failures := int(updatedFeed.ConsecutiveFailures)
+	if failures >= maxFailures {
+		err := db.DisableFeed(ctx, database.DisableFeedParams{
+			Now: now,
+			ID:  dbFeed.ID,
 		})
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				continue
-			}
-			log.Printf("Couldn't create post: %v", err)
-			continue
+			log.Printf("Couldn't disable feed %s: %v", dbFeed.Name, err)
+			return
 		}
+		log.Printf("Feed %s disabled after %d consecutive failures", dbFeed.Name, failures)
+		return
+	}
+
+	nextFetchAt := schedule.Backoff(now, failures, retryAfter)
+	err = db.UpdateFeedNextFetch(ctx, database.UpdateFeedNextFetchParams{
+		ID:          dbFeed.ID,
+		NextFetchAt: sql.NullTime{Time: nextFetchAt, Valid: true},
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		log.Printf("Couldn't schedule retry of feed %s: %v", dbFeed.Name, err)
+		return
+	}
+	log.Printf("Feed %s failed %d times in a row, retrying at %s", dbFeed.Name, failures, nextFetchAt.Format(time.RFC3339))
+}
+
+func scheduleNextFetch(ctx context.Context, db database.Store, dbFeed database.Feed, hints schedule.Hints) {
+	postDates, err := db.GetRecentPostDates(ctx, database.GetRecentPostDatesParams{
+		FeedID: dbFeed.ID,
+		Limit:  20,
+	})
+	if err != nil {
+		log.Printf("Couldn't get recent posts of feed %s: %v", dbFeed.Name, err)
+	}
+	for _, postDate := range postDates {
+		hints.PostTimes = append(hints.PostTimes, postDate.Time)
+	}
+
+	now := time.Now().UTC()
+	nextFetchAt := schedule.Next(now, hints)
+	err = db.UpdateFeedNextFetch(ctx, database.UpdateFeedNextFetchParams{
+		ID:          dbFeed.ID,
+		NextFetchAt: sql.NullTime{Time: nextFetchAt, Valid: true},
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		log.Printf("Couldn't schedule next fetch of feed %s: %v", dbFeed.Name, err)
+		return
 	}
-	log.Printf("Feed %s collected, %v posts found", feed.Name, len(feedData.Channel.Item))
+	log.Printf("Feed %s will be fetched again at %s", dbFeed.Name, nextFetchAt.Format(time.RFC3339))
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+// TestScrapeFeedsConcurrently runs several aggregators against one database
+// and checks that the leases share the feeds out: every feed is fetched, and
+// by a single aggregator.
+func TestScrapeFeedsConcurrently(t *testing.T) {
+	const (
+		aggregators = 4
+		feedCount   = 12
+	)
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	forEachBackend(t, func(t *testing.T, s *state) {
+		var (
+			mu       sync.Mutex
+			requests = map[string]int{}
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests[r.URL.Path]++
+			mu.Unlock()
+			// Keep the feed busy long enough for the other aggregators to
+			// look for work in the meantime.
+			time.Sleep(20 * time.Millisecond)
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprintf(w, `<rss version="2.0"><channel><title>Feed</title><item><title>Post</title><guid>%s</guid><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item></channel></rss>`, r.URL.Path)
+		}))
+		defer server.Close()
+
+		user := createTestUser(t, s.db, "alice")
+		for i := range feedCount {
+			createTestFeed(t, s.db, user, fmt.Sprintf("Feed %d", i), fmt.Sprintf("%s/feeds/%d", server.URL, i))
+		}
+
+		// Every round of an aggregator collects at least one feed unless all
+		// the feeds left are leased to others, so feedCount rounds each are
+		// plenty to collect them all.
+		var wg sync.WaitGroup
+		for i := range aggregators {
+			opts := aggregateOptions{
+				concurrency: 2,
+				maxFailures: 10,
+				limiter:     newHostLimiter(2, 0),
+				owner:       fmt.Sprintf("aggregator-%d", i),
+				lease:       time.Minute,
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range feedCount {
+					scrapeFeeds(context.Background(), s, opts)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(requests) != feedCount {
+			t.Errorf("%d feeds were fetched, want %d", len(requests), feedCount)
+		}
+		for path, count := range requests {
+			if count != 1 {
+				t.Errorf("%s was fetched %d times, want once", path, count)
+			}
+		}
+
+		feeds, err := s.db.GetFeeds(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dbFeed := range feeds {
+			if dbFeed.LeaseOwner.Valid || !dbFeed.NextFetchAt.Valid {
+				t.Errorf("%s is leased to %q with its next fetch at %v, want no lease and a next fetch", dbFeed.Name, dbFeed.LeaseOwner.String, dbFeed.NextFetchAt)
+			}
+		}
+	})
+}
+
+// TestAggregateDisabledFeed checks that agg --feed leaves a disabled feed
+// alone until it's enabled again.
+func TestAggregateDisabledFeed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprint(w, `<rss version="2.0"><channel><title>Feed</title></channel></rss>`)
+		}))
+		defer server.Close()
+
+		user := createTestUser(t, s.db, "alice")
+		dbFeed := createTestFeed(t, s.db, user, "Feed", server.URL)
+		if err := s.db.DisableFeed(context.Background(), database.DisableFeedParams{Now: time.Now().UTC(), ID: dbFeed.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		fs := flag.NewFlagSet("agg", flag.ContinueOnError)
+		aggregateFlags(fs)
+		if err := fs.Parse([]string{"--feed", server.URL}); err != nil {
+			t.Fatal(err)
+		}
+		err := handlerAggregate(s, command{Name: "agg", Flags: fs})
+		if err == nil || !strings.Contains(err.Error(), "enablefeed") {
+			t.Errorf("agg --feed on a disabled feed returned %v, want a hint to enable it", err)
+		}
+		if requests != 0 {
+			t.Errorf("the disabled feed was fetched %d times, want none", requests)
+		}
+	})
+}
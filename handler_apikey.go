@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/apikey"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"time"
+)
+
+func handlerAPIKey(s *state, cmd command, user database.User) error {
+	switch subcommand := cmd.Args[0]; {
+	case subcommand == "create" && len(cmd.Args) <= 2:
+		name := "default"
+		if len(cmd.Args) == 2 {
+			name = cmd.Args[1]
+		}
+		return createAPIKey(s, user, name)
+	case subcommand == "list" && len(cmd.Args) == 1:
+		return listAPIKeys(s, cmd, user)
+	case subcommand == "revoke" && len(cmd.Args) == 2:
+		return revokeAPIKey(s, user, cmd.Args[1])
+	default:
+		return fmt.Errorf("usage: %s create [name] | list | revoke <key_id>", cmd.Name)
+	}
+}
+
+func createAPIKey(s *state, user database.User, name string) error {
+	key, display, err := apikey.Generate()
+	if err != nil {
+		return err
+	}
+
+	created, err := s.db.CreateAPIKey(context.Background(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    display,
+		KeyHash:   apikey.Hash(key),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create API key: %w", err)
+	}
+
+	fmt.Println("API key created successfully:")
+	fmt.Printf("* ID:   %s\n", created.ID)
+	fmt.Printf("* Name: %s\n", created.Name)
+	fmt.Printf("* Key:  %s\n", key)
+	fmt.Println("Store the key now, it can't be shown again.")
+	return nil
+}
+
+func listAPIKeys(s *state, cmd command, user database.User) error {
+	keys, err := s.db.GetAPIKeysForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get API keys: %w", err)
+	}
+
+	records := make([]apiKeyRecord, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, apiKeyRecord{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			CreatedAt:  key.CreatedAt,
+			LastUsedAt: timeOrNil(key.LastUsedAt),
+			RevokedAt:  timeOrNil(key.RevokedAt),
+		})
+	}
+
+	return render(cmd, records, func() {
+		if len(records) == 0 {
+			fmt.Printf("No API keys found for user %s\n", user.Name)
+			return
+		}
+
+		fmt.Printf("API keys for user %s:\n", user.Name)
+		for _, key := range records {
+			lastUsed, status := "never", "active"
+			if key.LastUsedAt != nil {
+				lastUsed = key.LastUsedAt.String()
+			}
+			if key.RevokedAt != nil {
+				status = fmt.Sprintf("revoked on %v", *key.RevokedAt)
+			}
+			fmt.Printf("* ID:       %s\n", key.ID)
+			fmt.Printf("* Name:     %s\n", key.Name)
+			fmt.Printf("* Key:      %s...\n", key.Prefix)
+			fmt.Printf("* Created:  %v\n", key.CreatedAt)
+			fmt.Printf("* LastUsed: %s\n", lastUsed)
+			fmt.Printf("* Status:   %s\n", status)
+			fmt.Println("=====================================")
+		}
+	})
+}
+
+func revokeAPIKey(s *state, user database.User, keyID string) error {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return fmt.Errorf("invalid key id: %w", err)
+	}
+
+	revoked, err := s.db.RevokeAPIKey(context.Background(), database.RevokeAPIKeyParams{
+		ID:        id,
+		UserID:    user.ID,
+		RevokedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't revoke API key: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("no active API key %s for user %s", id, user.Name)
+	}
+
+	fmt.Printf("API key %s revoked\n", id)
+	return nil
+}
+
+type apiKeyRecord struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (apiKeyRecord) header() []string {
+	return []string{"id", "name", "prefix", "created_at", "last_used_at", "revoked_at"}
+}
+
+func (r apiKeyRecord) row() []string {
+	return []string{r.ID.String(), r.Name, r.Prefix, formatTime(&r.CreatedAt), formatTime(r.LastUsedAt), formatTime(r.RevokedAt)}
+}
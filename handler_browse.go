@@ -3,11 +3,25 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/peeta98/blog-aggregator/internal/database"
 	"strconv"
+	"time"
 )
 
+func browseFlags(fs *flag.FlagSet) {
+	fs.String("feed", "", "only show the posts of a `feed`, by name or url")
+	fs.String("since", "", "only show posts published after a `date` or within a duration")
+	fs.String("until", "", "only show posts published before a `date` or a duration ago")
+	fs.Bool("unread", true, "only show unread posts")
+	fs.Bool("all", false, "include posts already read, same as --unread=false")
+	fs.String("sort", string(database.SortPublished), "sort by published, fetched or feed")
+	fs.Bool("reverse", false, "reverse the sort order")
+	fs.Int("offset", 0, "skip this many posts, for paging")
+}
+
 func handlerBrowsePosts(s *state, cmd command, user database.User) error {
 	var limitPosts int32
 	if len(cmd.Args) != 1 {
@@ -23,20 +37,53 @@ func handlerBrowsePosts(s *state, cmd command, user database.User) error {
 		}
 		limitPosts = int32(limit)
 	}
+	offset := cmd.intFlag("offset")
+	if offset < 0 {
+		return errors.New("offset can't be negative")
+	}
 
-	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  limitPosts,
-	})
+	params := database.ListPostsForUserParams{
+		UserID:     user.ID,
+		Feed:       cmd.stringFlag("feed"),
+		UnreadOnly: cmd.boolFlag("unread") && !cmd.boolFlag("all"),
+		Sort:       database.PostSort(cmd.stringFlag("sort")),
+		Reverse:    cmd.boolFlag("reverse"),
+		Limit:      limitPosts,
+		Offset:     int32(offset),
+	}
+	switch params.Sort {
+	case database.SortPublished, database.SortFetched, database.SortFeed:
+	default:
+		return fmt.Errorf("invalid sort %q: must be published, fetched or feed", params.Sort)
+	}
+
+	var err error
+	now := time.Now().UTC()
+	if since := cmd.stringFlag("since"); since != "" {
+		if params.Since, err = parseSince(since, now); err != nil {
+			return err
+		}
+	}
+	if until := cmd.stringFlag("until"); until != "" {
+		if params.Until, err = parseSince(until, now); err != nil {
+			return err
+		}
+	}
+
+	posts, err := s.db.ListPostsForUser(context.Background(), params)
 	if err != nil {
 		return fmt.Errorf("couldn't get posts for user: %w", err)
 	}
 
-	printPosts(posts, user.Name)
-	return nil
+	return render(cmd, newPostRecords(posts), func() {
+		printPosts(posts, user.Name)
+		if len(posts) == int(limitPosts) {
+			fmt.Printf("More posts may be available with --offset %d\n", offset+len(posts))
+		}
+	})
 }
 
-func printPosts(posts []database.GetPostsForUserRow, userName string) {
+func printPosts(posts []database.ListPostsForUserRow, userName string) {
 	if len(posts) == 0 {
 		fmt.Printf("No posts found for user %s\n", userName)
 		return
@@ -44,10 +91,60 @@ func printPosts(posts []database.GetPostsForUserRow, userName string) {
 
 	fmt.Printf("Found %d posts for user %s:\n", len(posts), userName)
 	for _, post := range posts {
-		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
+		status := "unread"
+		if post.ReadAt.Valid {
+			status = "read"
+		}
+		fmt.Printf("%s from %s (%s)\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName, status)
 		fmt.Printf("--- %s ---\n", post.Title)
 		fmt.Printf("    %v\n", post.Description.String)
 		fmt.Printf("Link: %s\n", post.Url)
+		fmt.Printf("ID:   %s\n", post.ID)
 		fmt.Println("=====================================")
 	}
 }
+
+type postRecord struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	Feed        string     `json:"feed"`
+	PublishedAt *time.Time `json:"published_at"`
+	FetchedAt   time.Time  `json:"fetched_at"`
+	ReadAt      *time.Time `json:"read_at"`
+}
+
+func newPostRecords(posts []database.ListPostsForUserRow) []postRecord {
+	records := make([]postRecord, 0, len(posts))
+	for _, post := range posts {
+		records = append(records, postRecord{
+			ID:          post.ID,
+			Title:       post.Title,
+			URL:         post.Url,
+			Description: post.Description.String,
+			Feed:        post.FeedName,
+			PublishedAt: timeOrNil(post.PublishedAt),
+			FetchedAt:   post.CreatedAt,
+			ReadAt:      timeOrNil(post.ReadAt),
+		})
+	}
+	return records
+}
+
+func (postRecord) header() []string {
+	return []string{"id", "title", "url", "description", "feed", "published_at", "fetched_at", "read_at"}
+}
+
+func (r postRecord) row() []string {
+	return []string{
+		r.ID.String(),
+		r.Title,
+		r.URL,
+		r.Description,
+		r.Feed,
+		formatTime(r.PublishedAt),
+		formatTime(&r.FetchedAt),
+		formatTime(r.ReadAt),
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/opml"
+	"os"
+	"time"
+)
+
+func handlerExport(s *state, cmd command, user database.User) error {
+	feedFollows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed follows: %w", err)
+	}
+
+	subscriptions := make([]opml.Subscription, 0, len(feedFollows))
+	for _, ff := range feedFollows {
+		subscriptions = append(subscriptions, opml.Subscription{
+			Title:   ff.FeedName,
+			FeedURL: ff.FeedUrl,
+			SiteURL: ff.FeedSiteUrl.String,
+			Folder:  ff.Folder.String,
+		})
+	}
+
+	title := fmt.Sprintf("%s's subscriptions", user.Name)
+	if len(cmd.Args) == 0 {
+		return opml.Write(os.Stdout, title, time.Now().UTC(), subscriptions)
+	}
+
+	file, err := os.Create(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't create OPML file: %w", err)
+	}
+	err = opml.Write(file, title, time.Now().UTC(), subscriptions)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("couldn't write OPML file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d feeds to %s\n", len(subscriptions), cmd.Args[0])
+	return nil
+}
@@ -2,26 +2,58 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/peeta98/blog-aggregator/internal/database"
+	feedparser "github.com/peeta98/blog-aggregator/internal/feed"
+	"github.com/peeta98/blog-aggregator/internal/schedule"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 2 {
-		return fmt.Errorf("usage: %s <name> <url>", cmd.Name)
+	feedName := ""
+	feedUrl := cmd.Args[0]
+	if len(cmd.Args) == 2 {
+		feedName = cmd.Args[0]
+		feedUrl = cmd.Args[1]
 	}
-
-	feedName := cmd.Args[0]
-	feedUrl := cmd.Args[1]
 	err := validateFeedUrl(feedUrl)
 	if err != nil {
 		return fmt.Errorf("couldn't validate feed url: %w", err)
 	}
 
+	candidates, err := feedparser.Discover(context.Background(), feedUrl)
+	if err != nil {
+		return fmt.Errorf("couldn't find a feed at %s: %w", feedUrl, err)
+	}
+	candidate, err := chooseFeedCandidate(candidates)
+	if err != nil {
+		return err
+	}
+
+	// Discovery already downloaded the feed unless it came from a page
+	feedUrl = candidate.URL
+	parsedFeed := candidate.Feed
+	if parsedFeed == nil {
+		res, err := feedparser.Fetch(context.Background(), feedparser.Request{URL: candidate.URL})
+		if err != nil {
+			return fmt.Errorf("couldn't fetch feed %s: %w", candidate.URL, err)
+		}
+		feedUrl = res.URL
+		parsedFeed = res.Feed
+	}
+	if feedName == "" {
+		feedName = parsedFeed.Title
+	}
+	if feedName == "" {
+		feedName = feedUrl
+	}
+
 	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
 		ID:        uuid.New(),
 		CreatedAt: time.Now().UTC(),
@@ -60,24 +92,149 @@ func handlerListFeeds(s *state, cmd command) error {
 		return fmt.Errorf("couldn't get feeds: %w", err)
 	}
 
-	if len(feeds) == 0 {
-		fmt.Println("No feeds found.")
-		return nil
-	}
-
-	fmt.Printf("Found %d feeds:\n", len(feeds))
+	users := make([]database.User, 0, len(feeds))
+	records := make([]feedRecord, 0, len(feeds))
 	for _, feed := range feeds {
 		user, err := s.db.GetUserById(context.Background(), feed.UserID)
 		if err != nil {
 			return fmt.Errorf("couldn't get user: %w", err)
 		}
-		printFeed(feed, user)
-		fmt.Println("=====================================")
+		users = append(users, user)
+		records = append(records, newFeedRecord(feed, user))
+	}
+
+	return render(cmd, records, func() {
+		if len(feeds) == 0 {
+			fmt.Println("No feeds found.")
+			return
+		}
+
+		fmt.Printf("Found %d feeds:\n", len(feeds))
+		for i, feed := range feeds {
+			printFeed(feed, users[i])
+			fmt.Println("=====================================")
+		}
+	})
+}
+
+type feedRecord struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	User          string     `json:"user"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	NextFetchAt   *time.Time `json:"next_fetch_at"`
+	Interval      string     `json:"interval"`
+}
+
+func newFeedRecord(feed database.Feed, user database.User) feedRecord {
+	return feedRecord{
+		ID:            feed.ID,
+		Name:          feed.Name,
+		URL:           feed.Url,
+		User:          user.Name,
+		CreatedAt:     feed.CreatedAt,
+		UpdatedAt:     feed.UpdatedAt,
+		LastFetchedAt: timeOrNil(feed.LastFetchedAt),
+		NextFetchAt:   timeOrNil(feed.NextFetchAt),
+		Interval:      formatFetchInterval(feed.FetchIntervalSeconds),
+	}
+}
+
+func (feedRecord) header() []string {
+	return []string{"id", "name", "url", "user", "created_at", "updated_at", "last_fetched_at", "next_fetch_at", "interval"}
+}
+
+func (r feedRecord) row() []string {
+	return []string{
+		r.ID.String(),
+		r.Name,
+		r.URL,
+		r.User,
+		formatTime(&r.CreatedAt),
+		formatTime(&r.UpdatedAt),
+		formatTime(r.LastFetchedAt),
+		formatTime(r.NextFetchAt),
+		r.Interval,
+	}
+}
+
+func handlerSetFeedInterval(s *state, cmd command, user database.User) error {
+	feedUrl := cmd.Args[0]
+	if err := validateFeedUrl(feedUrl); err != nil {
+		return err
+	}
+
+	interval := sql.NullInt32{}
+	if cmd.Args[1] != "auto" {
+		duration, err := time.ParseDuration(cmd.Args[1])
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		if duration < schedule.MinInterval {
+			return fmt.Errorf("interval must be at least %s", schedule.MinInterval)
+		}
+		if duration > schedule.MaxConfiguredInterval {
+			return fmt.Errorf("interval must be at most %s", schedule.MaxConfiguredInterval)
+		}
+		interval = sql.NullInt32{
+			Int32: int32(duration / time.Second),
+			Valid: true,
+		}
+	}
+
+	feed, err := s.db.GetFeedByUrl(context.Background(), feedUrl)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed: %w", err)
+	}
+	if feed.UserID != user.ID {
+		return errors.New("only the user who added a feed can change its interval")
+	}
+
+	feed, err = s.db.UpdateFeedFetchInterval(context.Background(), database.UpdateFeedFetchIntervalParams{
+		ID:                   feed.ID,
+		FetchIntervalSeconds: interval,
+		UpdatedAt:            time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't update feed interval: %w", err)
 	}
 
+	fmt.Println("Feed interval updated successfully:")
+	printFeed(feed, user)
 	return nil
 }
 
+// chooseFeedCandidate asks the user to pick one of the discovered feeds
+// when there is more than one.
+func chooseFeedCandidate(candidates []feedparser.Candidate) (feedparser.Candidate, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	fmt.Println("Found multiple feeds:")
+	for i, candidate := range candidates {
+		title := candidate.Title
+		if title == "" {
+			title = candidate.Type
+		}
+		fmt.Printf("  %d) %s (%s)\n", i+1, title, candidate.URL)
+	}
+	fmt.Printf("Pick a feed [1-%d]: ", len(candidates))
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return feedparser.Candidate{}, errors.New("multiple feeds found, pass the URL of the one to add")
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return feedparser.Candidate{}, fmt.Errorf("invalid choice %q", strings.TrimSpace(line))
+	}
+	return candidates[choice-1], nil
+}
+
 func validateFeedUrl(feedUrl string) error {
 	parsedUrl, err := url.Parse(feedUrl)
 	if err != nil {
@@ -97,4 +254,13 @@ func printFeed(feed database.Feed, user database.User) {
 	fmt.Printf("* URL:           %s\n", feed.Url)
 	fmt.Printf("* User:          %s\n", user.Name)
 	fmt.Printf("* LastFetchedAt: %v\n", feed.LastFetchedAt.Time)
+	fmt.Printf("* NextFetchAt:   %v\n", feed.NextFetchAt.Time)
+	fmt.Printf("* Interval:      %s\n", formatFetchInterval(feed.FetchIntervalSeconds))
+}
+
+func formatFetchInterval(interval sql.NullInt32) string {
+	if !interval.Valid {
+		return "auto"
+	}
+	return (time.Duration(interval.Int32) * time.Second).String()
 }
@@ -5,14 +5,11 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/peeta98/blog-aggregator/internal/database"
+	"strconv"
 	"time"
 )
 
 func handlerFollowFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %s <feed_url>", cmd.Name)
-	}
-
 	feedUrl := cmd.Args[0]
 	if err := validateFeedUrl(feedUrl); err != nil {
 		return err
@@ -45,24 +42,47 @@ func handlerListFeedFollows(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("couldn't get feed follows: %w", err)
 	}
 
-	if len(feedFollows) == 0 {
-		fmt.Println("No feed follows found for this user.")
-		return nil
-	}
-
-	fmt.Printf("Feed follows for user %s:\n", user.Name)
+	records := make([]feedFollowRecord, 0, len(feedFollows))
 	for _, ff := range feedFollows {
-		fmt.Printf("* %s\n", ff.FeedName)
+		records = append(records, feedFollowRecord{
+			FeedName:   ff.FeedName,
+			FeedURL:    ff.FeedUrl,
+			Folder:     ff.Folder.String,
+			Unread:     ff.UnreadCount,
+			FollowedAt: ff.CreatedAt,
+		})
 	}
 
-	return nil
+	return render(cmd, records, func() {
+		if len(feedFollows) == 0 {
+			fmt.Println("No feed follows found for this user.")
+			return
+		}
+
+		fmt.Printf("Feed follows for user %s:\n", user.Name)
+		for _, ff := range feedFollows {
+			fmt.Printf("* %s (%d unread)\n", ff.FeedName, ff.UnreadCount)
+		}
+	})
 }
 
-func handlerUnfollowFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %v <feed_url>", cmd.Name)
-	}
+type feedFollowRecord struct {
+	FeedName   string    `json:"feed_name"`
+	FeedURL    string    `json:"feed_url"`
+	Folder     string    `json:"folder"`
+	Unread     int64     `json:"unread"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (feedFollowRecord) header() []string {
+	return []string{"feed_name", "feed_url", "folder", "unread", "followed_at"}
+}
 
+func (r feedFollowRecord) row() []string {
+	return []string{r.FeedName, r.FeedURL, r.Folder, strconv.FormatInt(r.Unread, 10), formatTime(&r.FollowedAt)}
+}
+
+func handlerUnfollowFeed(s *state, cmd command, user database.User) error {
 	feedUrl := cmd.Args[0]
 	if err := validateFeedUrl(feedUrl); err != nil {
 		return err
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"strconv"
+	"time"
+)
+
+func handlerFeedStatus(s *state, cmd command) error {
+	feeds, err := s.db.GetFailingFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't get failing feeds: %w", err)
+	}
+
+	records := make([]feedStatusRecord, 0, len(feeds))
+	for _, feed := range feeds {
+		record := feedStatusRecord{
+			Name:        feed.Name,
+			URL:         feed.Url,
+			Status:      "failing",
+			Failures:    feed.ConsecutiveFailures,
+			LastError:   feed.LastError.String,
+			LastErrorAt: timeOrNil(feed.LastErrorAt),
+			DisabledAt:  timeOrNil(feed.DisabledAt),
+		}
+		if feed.DisabledAt.Valid {
+			record.Status = "disabled"
+		} else {
+			record.NextFetchAt = timeOrNil(feed.NextFetchAt)
+		}
+		if feed.LastStatusCode.Valid {
+			record.StatusCode = &feed.LastStatusCode.Int32
+		}
+		records = append(records, record)
+	}
+
+	return render(cmd, records, func() {
+		if len(feeds) == 0 {
+			fmt.Println("All feeds are healthy.")
+			return
+		}
+
+		fmt.Printf("Found %d feeds with errors:\n", len(feeds))
+		for _, feed := range feeds {
+			printFeedStatus(feed)
+			fmt.Println("=====================================")
+		}
+	})
+}
+
+type feedStatusRecord struct {
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	Status      string     `json:"status"`
+	Failures    int32      `json:"failures"`
+	StatusCode  *int32     `json:"status_code"`
+	LastError   string     `json:"last_error"`
+	LastErrorAt *time.Time `json:"last_error_at"`
+	DisabledAt  *time.Time `json:"disabled_at"`
+	NextFetchAt *time.Time `json:"next_fetch_at"`
+}
+
+func (feedStatusRecord) header() []string {
+	return []string{"name", "url", "status", "failures", "status_code", "last_error", "last_error_at", "disabled_at", "next_fetch_at"}
+}
+
+func (r feedStatusRecord) row() []string {
+	statusCode := ""
+	if r.StatusCode != nil {
+		statusCode = strconv.Itoa(int(*r.StatusCode))
+	}
+	return []string{
+		r.Name,
+		r.URL,
+		r.Status,
+		strconv.Itoa(int(r.Failures)),
+		statusCode,
+		r.LastError,
+		formatTime(r.LastErrorAt),
+		formatTime(r.DisabledAt),
+		formatTime(r.NextFetchAt),
+	}
+}
+
+func handlerEnableFeed(s *state, cmd command) error {
+	feedUrl := cmd.Args[0]
+	if err := validateFeedUrl(feedUrl); err != nil {
+		return err
+	}
+
+	feed, err := s.db.GetFeedByUrl(context.Background(), feedUrl)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed: %w", err)
+	}
+
+	feed, err = s.db.EnableFeed(context.Background(), database.EnableFeedParams{
+		ID:        feed.ID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't enable feed: %w", err)
+	}
+
+	fmt.Printf("%s enabled, it will be fetched on the next aggregation run!\n", feed.Name)
+	return nil
+}
+
+func printFeedStatus(feed database.Feed) {
+	status := "failing"
+	if feed.DisabledAt.Valid {
+		status = fmt.Sprintf("disabled since %v", feed.DisabledAt.Time)
+	}
+
+	fmt.Printf("* Name:        %s\n", feed.Name)
+	fmt.Printf("* URL:         %s\n", feed.Url)
+	fmt.Printf("* Status:      %s\n", status)
+	fmt.Printf("* Failures:    %d\n", feed.ConsecutiveFailures)
+	if feed.LastStatusCode.Valid {
+		fmt.Printf("* StatusCode:  %d\n", feed.LastStatusCode.Int32)
+	}
+	fmt.Printf("* LastError:   %s\n", feed.LastError.String)
+	fmt.Printf("* LastErrorAt: %v\n", feed.LastErrorAt.Time)
+	if !feed.DisabledAt.Valid {
+		fmt.Printf("* NextFetchAt: %v\n", feed.NextFetchAt.Time)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func (c *commands) handlerHelp(s *state, cmd command) error {
+	if len(cmd.Args) == 1 {
+		registered, ok := c.registeredCommands[cmd.Args[0]]
+		if !ok {
+			return c.unknownCommand(cmd.Args[0])
+		}
+		printCommandHelp(registered.spec)
+		return nil
+	}
+
+	fmt.Println("Usage: blog-aggregator <command> [args...]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, name := range c.names() {
+		fmt.Printf("  %-12s %s\n", name, c.registeredCommands[name].spec.Summary)
+	}
+	fmt.Println()
+	fmt.Println("Global flags:")
+	global := flag.NewFlagSet("global", flag.ContinueOnError)
+	globalFlags(global)
+	printFlags(global)
+	fmt.Println()
+	fmt.Println("Run 'help <command>' for the arguments and flags of a command.")
+	return nil
+}
+
+func printCommandHelp(spec commandSpec) {
+	fmt.Println(spec.usage())
+	fmt.Println()
+	fmt.Println(spec.Summary)
+	if spec.LoginRequired {
+		fmt.Println("Requires a logged in user.")
+	}
+
+	if !spec.hasFlags() {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Flags:")
+	printFlags(spec.commandFlagSet())
+}
+
+func printFlags(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		line := "  --" + f.Name
+		if name != "" {
+			line += " " + name
+		}
+		line = fmt.Sprintf("%-26s %s", line, usage)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			line += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Println(line)
+	})
+}
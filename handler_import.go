@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/opml"
+	"os"
+	"time"
+)
+
+type importSummary struct {
+	created   int
+	existing  int
+	followed  int
+	duplicate int
+	invalid   []string
+}
+
+func handlerImport(s *state, cmd command, user database.User) error {
+	file, err := os.Open(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't open OPML file: %w", err)
+	}
+	defer file.Close()
+
+	subscriptions, err := opml.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	summary, err := importSubscriptions(s.backend.newStore(tx), user, subscriptions)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit import: %w", err)
+	}
+
+	fmt.Printf("Imported %d feeds for user %s:\n", summary.created+summary.existing, user.Name)
+	fmt.Printf("* Created:   %d\n", summary.created)
+	fmt.Printf("* Existing:  %d\n", summary.existing)
+	fmt.Printf("* Followed:  %d\n", summary.followed)
+	fmt.Printf("* Duplicate: %d\n", summary.duplicate)
+	fmt.Printf("* Invalid:   %d\n", len(summary.invalid))
+	for _, reason := range summary.invalid {
+		fmt.Printf("    %s\n", reason)
+	}
+	return nil
+}
+
+// importSubscriptions creates the feeds that don't exist yet and follows
+// every valid subscription. A feed listed more than once is imported from
+// its first entry. Any database error aborts the whole import.
+func importSubscriptions(db database.Store, user database.User, subscriptions []opml.Subscription) (importSummary, error) {
+	var summary importSummary
+	seen := make(map[string]bool)
+	for _, sub := range subscriptions {
+		if err := validateFeedUrl(sub.FeedURL); err != nil {
+			summary.invalid = append(summary.invalid, fmt.Sprintf("%q: %v", sub.Title, err))
+			continue
+		}
+		if seen[sub.FeedURL] {
+			summary.duplicate++
+			continue
+		}
+		seen[sub.FeedURL] = true
+
+		feed, err := db.GetFeedByUrl(context.Background(), sub.FeedURL)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			name := sub.Title
+			if name == "" {
+				name = sub.FeedURL
+			}
+			feed, err = db.CreateFeed(context.Background(), database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(),
+				Name:      name,
+				Url:       sub.FeedURL,
+				UserID:    user.ID,
+			})
+			if err != nil {
+				return summary, fmt.Errorf("couldn't create feed %s: %w", sub.FeedURL, err)
+			}
+			summary.created++
+		case err != nil:
+			return summary, fmt.Errorf("couldn't get feed %s: %w", sub.FeedURL, err)
+		default:
+			summary.existing++
+		}
+
+		_, err = db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+		})
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return summary, fmt.Errorf("couldn't get feed follow: %w", err)
+		}
+
+		_, err = db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			FeedID:    feed.ID,
+			UserID:    user.ID,
+			Folder:    sql.NullString{String: sub.Folder, Valid: sub.Folder != ""},
+		})
+		if err != nil {
+			return summary, fmt.Errorf("couldn't follow feed %s: %w", sub.FeedURL, err)
+		}
+		summary.followed++
+	}
+	return summary, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/pressly/goose/v3"
+	"strconv"
+	"time"
+)
+
+func handlerMigrate(s *state, cmd command) error {
+	provider, err := newMigrationProvider(s)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch cmd.Args[0] {
+	case "up":
+		results, err := provider.Up(ctx)
+		printMigrationResults(results)
+		if err != nil {
+			return fmt.Errorf("couldn't apply migrations: %w", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("Database schema is up to date.")
+		}
+	case "down":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't roll back migration: %w", err)
+		}
+		printMigrationResults([]*goose.MigrationResult{result})
+	case "redo":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't roll back migration: %w", err)
+		}
+		printMigrationResults([]*goose.MigrationResult{result})
+		result, err = provider.UpByOne(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't apply migration: %w", err)
+		}
+		printMigrationResults([]*goose.MigrationResult{result})
+	case "status":
+		return printMigrationStatus(ctx, cmd, provider)
+	default:
+		return fmt.Errorf("usage: %s up|down|status|redo", cmd.Name)
+	}
+	return nil
+}
+
+func printMigrationResults(results []*goose.MigrationResult) {
+	for _, result := range results {
+		fmt.Printf("* %-5s %s (%s)\n", result.Direction, result.Source.Path, result.Duration.Round(time.Millisecond))
+	}
+}
+
+func printMigrationStatus(ctx context.Context, cmd command, provider *goose.Provider) error {
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get migration status: %w", err)
+	}
+
+	records := make([]migrationRecord, 0, len(statuses))
+	for _, status := range statuses {
+		record := migrationRecord{
+			Version: status.Source.Version,
+			Name:    status.Source.Path,
+			Applied: status.State == goose.StateApplied,
+		}
+		if record.Applied {
+			appliedAt := status.AppliedAt
+			record.AppliedAt = &appliedAt
+		}
+		records = append(records, record)
+	}
+
+	return render(cmd, records, func() {
+		for _, record := range records {
+			state := "pending"
+			if record.Applied {
+				state = fmt.Sprintf("applied %v", record.AppliedAt.Format(time.DateTime))
+			}
+			fmt.Printf("* %-32s %s\n", record.Name, state)
+		}
+	})
+}
+
+type migrationRecord struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func (migrationRecord) header() []string {
+	return []string{"version", "name", "applied", "applied_at"}
+}
+
+func (r migrationRecord) row() []string {
+	return []string{strconv.FormatInt(r.Version, 10), r.Name, strconv.FormatBool(r.Applied), formatTime(r.AppliedAt)}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/dateparse"
+	"time"
+)
+
+func handlerReadPost(s *state, cmd command, user database.User) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %w", err)
+	}
+
+	rows, err := s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mark post as read: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("post %s not found in your feeds", postID)
+	}
+
+	fmt.Printf("Post %s marked as read\n", postID)
+	return nil
+}
+
+func handlerUnreadPost(s *state, cmd command, user database.User) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %w", err)
+	}
+
+	rows, err := s.db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mark post as unread: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("post %s not found in your read posts", postID)
+	}
+
+	fmt.Printf("Post %s marked as unread\n", postID)
+	return nil
+}
+
+func markReadFlags(fs *flag.FlagSet) {
+	fs.String("feed", "", "mark the posts of a feed as read, by `url`")
+	fs.String("before", "", "mark the posts published before a `date` as read")
+	fs.Bool("all", false, "mark every post as read")
+}
+
+func handlerMarkRead(s *state, cmd command, user database.User) error {
+	feedUrl := cmd.stringFlag("feed")
+	before := cmd.stringFlag("before")
+	all := cmd.boolFlag("all")
+
+	now := time.Now().UTC()
+	var (
+		marked int64
+		err    error
+	)
+	switch {
+	case feedUrl != "" && before == "" && !all:
+		feed, err := s.db.GetFeedByUrl(context.Background(), feedUrl)
+		if err != nil {
+			return fmt.Errorf("couldn't get feed: %w", err)
+		}
+		marked, err = s.db.MarkFeedPostsRead(context.Background(), database.MarkFeedPostsReadParams{
+			ReadAt: now,
+			UserID: user.ID,
+			FeedID: feed.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark feed posts as read: %w", err)
+		}
+	case before != "" && feedUrl == "" && !all:
+		publishedBefore, err := dateparse.Parse(before)
+		if err != nil {
+			return fmt.Errorf("invalid date %q: %w", before, err)
+		}
+		marked, err = s.db.MarkPostsReadBefore(context.Background(), database.MarkPostsReadBeforeParams{
+			ReadAt:          now,
+			UserID:          user.ID,
+			PublishedBefore: publishedBefore,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts as read: %w", err)
+		}
+	case all && feedUrl == "" && before == "":
+		marked, err = s.db.MarkAllPostsRead(context.Background(), database.MarkAllPostsReadParams{
+			ReadAt: now,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts as read: %w", err)
+		}
+	default:
+		return fmt.Errorf("usage: %s --feed <feed_url> | --before <date> | --all", cmd.Name)
+	}
+
+	fmt.Printf("%d posts marked as read\n", marked)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/dateparse"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func searchFlags(fs *flag.FlagSet) {
+	fs.String("feed", "", "only search the posts of a `feed`, by name or url")
+	fs.String("since", "", "only search posts published after a `date` or within a duration")
+	fs.Int("limit", 10, "maximum number of results")
+}
+
+func handlerSearchPosts(s *state, cmd command, user database.User) error {
+	limit := cmd.intFlag("limit")
+	if limit <= 0 {
+		return errors.New("limit must be a positive number")
+	}
+
+	// The query may be given unquoted, e.g. `search golang generics`
+	query := strings.Join(cmd.Args, " ")
+	feed := cmd.stringFlag("feed")
+	params := database.SearchPostsForUserParams{
+		Query:     query,
+		UserID:    user.ID,
+		Feed:      sql.NullString{String: feed, Valid: feed != ""},
+		PostLimit: int32(limit),
+	}
+	if since := cmd.stringFlag("since"); since != "" {
+		sinceTime, err := parseSince(since, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: sinceTime, Valid: true}
+	}
+
+	results, err := s.db.SearchPostsForUser(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't search posts: %w", err)
+	}
+
+	records := make([]searchResultRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, searchResultRecord{
+			ID:          result.ID,
+			Title:       result.Title,
+			URL:         result.Url,
+			Feed:        result.FeedName,
+			FeedURL:     result.FeedUrl,
+			PublishedAt: timeOrNil(result.PublishedAt),
+			Rank:        result.Rank,
+			Snippet:     strings.Join(strings.Fields(result.Snippet), " "),
+		})
+	}
+
+	return render(cmd, records, func() {
+		if len(records) == 0 {
+			fmt.Printf("No posts found matching %q\n", query)
+			return
+		}
+
+		fmt.Printf("Found %d posts matching %q:\n", len(records), query)
+		for _, result := range records {
+			published := time.Time{}
+			if result.PublishedAt != nil {
+				published = *result.PublishedAt
+			}
+			fmt.Printf("%s from %s (rank %.3f)\n", published.Format("Mon Jan 2"), result.Feed, result.Rank)
+			fmt.Printf("--- %s ---\n", result.Title)
+			if result.Snippet != "" {
+				fmt.Printf("    %s\n", result.Snippet)
+			}
+			fmt.Printf("Link: %s\n", result.URL)
+			fmt.Printf("ID:   %s\n", result.ID)
+			fmt.Println("=====================================")
+		}
+	})
+}
+
+type searchResultRecord struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	FeedURL     string     `json:"feed_url"`
+	PublishedAt *time.Time `json:"published_at"`
+	Rank        float32    `json:"rank"`
+	Snippet     string     `json:"snippet"`
+}
+
+func (searchResultRecord) header() []string {
+	return []string{"id", "title", "url", "feed", "feed_url", "published_at", "rank", "snippet"}
+}
+
+func (r searchResultRecord) row() []string {
+	return []string{
+		r.ID.String(),
+		r.Title,
+		r.URL,
+		r.Feed,
+		r.FeedURL,
+		formatTime(r.PublishedAt),
+		strconv.FormatFloat(float64(r.Rank), 'f', 4, 32),
+		r.Snippet,
+	}
+}
+
+// parseSince accepts either a point in time ("2024-05-01", "Jan 2 2024") or a
+// duration counted back from now ("36h", "7d", "2w"). The result is in UTC,
+// like the times stored in the database.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := parseDays(value); err == nil {
+		return now.Add(-d).UTC(), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).UTC(), nil
+	}
+	t, err := dateparse.Parse(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date or duration %q: %w", value, err)
+	}
+	return t, nil
+}
+
+// parseDays handles the day and week units time.ParseDuration doesn't know.
+func parseDays(value string) (time.Duration, error) {
+	unit := 24 * time.Hour
+	switch {
+	case strings.HasSuffix(value, "d"):
+	case strings.HasSuffix(value, "w"):
+		unit *= 7
+	default:
+		return 0, errors.New("missing day or week unit")
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number of days in %q", value)
+	}
+	return time.Duration(n) * unit, nil
+}
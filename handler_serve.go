@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/api"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func serveFlags(fs *flag.FlagSet) {
+	fs.String("addr", "localhost:8080", "`address` to listen on")
+}
+
+func handlerServe(s *state, cmd command) error {
+	server := &http.Server{
+		Addr:              cmd.stringFlag("addr"),
+		Handler:           api.New(s.dbConn, s.backend.newStore),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return fmt.Errorf("couldn't serve the API: %w", err)
+	}
+
+	// ctx ends on the first interrupt and stops accepting connections,
+	// shutdownCtx lets the requests in flight finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdownCtx, cancelRequests := drainContext(ctx, "requests")
+	defer cancelRequests()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	log.Printf("Serving the API on http://%s/v1", server.Addr)
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("couldn't serve the API: %w", err)
+	case <-ctx.Done():
+	}
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		if errors.Is(err, context.Canceled) {
+			return errors.New("requests in flight were interrupted by the shutdown")
+		}
+		return fmt.Errorf("couldn't shut down the API: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"time"
+)
+
+func handlerStarPost(s *state, cmd command, user database.User) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %w", err)
+	}
+
+	starred, err := s.db.StarPost(context.Background(), database.StarPostParams{
+		UserID:    user.ID,
+		StarredAt: time.Now().UTC(),
+		PostID:    postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't star post: %w", err)
+	}
+	if starred == 0 {
+		return fmt.Errorf("post %s not found in your feeds", postID)
+	}
+
+	fmt.Printf("Post %s starred\n", postID)
+	return nil
+}
+
+func handlerUnstarPost(s *state, cmd command, user database.User) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %w", err)
+	}
+
+	unstarred, err := s.db.UnstarPost(context.Background(), database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unstar post: %w", err)
+	}
+	if unstarred == 0 {
+		return fmt.Errorf("post %s is not starred", postID)
+	}
+
+	fmt.Printf("Post %s unstarred\n", postID)
+	return nil
+}
+
+func handlerListStarredPosts(s *state, cmd command, user database.User) error {
+	starred, err := s.db.GetStarredPostsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get starred posts: %w", err)
+	}
+
+	posts := make([]database.ListPostsForUserRow, 0, len(starred))
+	for _, post := range starred {
+		posts = append(posts, database.ListPostsForUserRow{
+			ID:          post.PostID,
+			CreatedAt:   post.FetchedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			PublishedAt: post.PublishedAt,
+			FeedName:    post.FeedName,
+			ReadAt:      post.ReadAt,
+		})
+	}
+
+	return render(cmd, newPostRecords(posts), func() {
+		printPosts(posts, user.Name)
+	})
+}
@@ -2,35 +2,48 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/peeta98/blog-aggregator/internal/database"
-	"strings"
+	"strconv"
 	"time"
 )
 
 func handlerRegister(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %v <name>", cmd.Name)
+	username := cmd.Args[0]
+	// Check the name before asking for a password. CreateUser still reports
+	// a conflict when someone else registers it in the meantime.
+	_, err := s.db.GetUser(context.Background(), username)
+	if err == nil {
+		return fmt.Errorf("user %s already exists", username)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("couldn't get user: %v", err)
+	}
+
+	passwordHash, err := promptNewPassword()
+	if err != nil {
+		return err
 	}
 
-	username := cmd.Args[0]
 	user, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      username,
+		ID:           uuid.New(),
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		Name:         username,
+		PasswordHash: passwordHash,
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "unique constraint") {
-			return fmt.Errorf("user %s already exists: %v", username, err)
+		if errors.Is(err, database.ErrConflict) {
+			return fmt.Errorf("user %s already exists", username)
 		}
 		return fmt.Errorf("couldn't create user: %v", err)
 	}
 
-	err = s.cfg.SetUser(user.Name)
-	if err != nil {
-		return fmt.Errorf("couldn't set current user: %w", err)
+	if err := startSession(s, user); err != nil {
+		return err
 	}
 
 	fmt.Println("User created successfully:")
@@ -39,36 +52,104 @@ func handlerRegister(s *state, cmd command) error {
 }
 
 func handlerLogin(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %v <name>", cmd.Name)
-	}
-
 	username := cmd.Args[0]
-	_, err := s.db.GetUser(context.Background(), username)
+	user, err := s.db.GetUser(context.Background(), username)
 	if err != nil {
 		return fmt.Errorf("username doesn't exist: %v", err)
 	}
 
-	err = s.cfg.SetUser(username)
-	if err != nil {
-		return fmt.Errorf("couldn't set current user: %w", err)
+	if err := checkPassword(user); err != nil {
+		return err
+	}
+	if err := startSession(s, user); err != nil {
+		return err
 	}
 
 	fmt.Printf("User '%s' is now logged in!\n", username)
 	return nil
 }
 
+func handlerLogout(s *state, cmd command) error {
+	if s.cfg.SessionToken != "" {
+		if err := s.db.DeleteSession(context.Background(), hashToken(s.cfg.SessionToken)); err != nil {
+			return fmt.Errorf("couldn't end session: %w", err)
+		}
+	}
+	if err := s.cfg.SetSession("", ""); err != nil {
+		return fmt.Errorf("couldn't clear current user: %w", err)
+	}
+
+	fmt.Println("Logged out successfully!")
+	return nil
+}
+
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	if err := checkPassword(user); err != nil {
+		return err
+	}
+	passwordHash, err := promptNewPassword()
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SetUserPassword(context.Background(), database.SetUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: passwordHash,
+		UpdatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set password: %w", err)
+	}
+
+	// Log out every other machine, then log this one back in
+	if err := s.db.DeleteUserSessions(context.Background(), user.ID); err != nil {
+		return fmt.Errorf("couldn't end sessions: %w", err)
+	}
+	s.cfg.SessionToken = ""
+	if err := startSession(s, user); err != nil {
+		return err
+	}
+
+	if passwordHash.Valid {
+		fmt.Println("Password updated successfully!")
+	} else {
+		fmt.Println("Password removed successfully!")
+	}
+	return nil
+}
+
 func handlerListUsers(s *state, cmd command) error {
 	users, err := s.db.GetUsers(context.Background())
 	if err != nil {
 		return fmt.Errorf("couldn't list users: %v\n", err)
 	}
 
+	records := make([]userRecord, 0, len(users))
 	for _, user := range users {
-		fmt.Print(formatUser(user.Name, s.cfg.CurrentUserName))
+		records = append(records, userRecord{
+			Name:    user.Name,
+			Current: user.Name == s.cfg.CurrentUserName,
+		})
 	}
 
-	return nil
+	return render(cmd, records, func() {
+		for _, user := range users {
+			fmt.Print(formatUser(user.Name, s.cfg.CurrentUserName))
+		}
+	})
+}
+
+type userRecord struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+func (userRecord) header() []string {
+	return []string{"name", "current"}
+}
+
+func (r userRecord) row() []string {
+	return []string{r.Name, strconv.FormatBool(r.Current)}
 }
 
 func formatUser(username, currentUser string) string {
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// hostLimiter keeps the aggregator polite: it bounds the number of
+// concurrent requests to each host and spaces out consecutive requests to
+// the same host by at least minDelay.
+type hostLimiter struct {
+	maxConcurrent int
+	minDelay      time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+type hostSlot struct {
+	slots chan struct{}
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newHostLimiter(maxConcurrent int, minDelay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		maxConcurrent: maxConcurrent,
+		minDelay:      minDelay,
+		hosts:         make(map[string]*hostSlot),
+	}
+}
+
+// acquire blocks until a request to the host of rawURL may start. The
+// returned function must be called once the request is done.
+func (l *hostLimiter) acquire(ctx context.Context, rawURL string) (func(), error) {
+	slot := l.slot(hostOf(rawURL))
+
+	select {
+	case slot.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-slot.slots }
+
+	slot.mu.Lock()
+	now := time.Now()
+	start := slot.next
+	if start.Before(now) {
+		start = now
+	}
+	slot.next = start.Add(l.minDelay)
+	slot.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+func (l *hostLimiter) slot(host string) *hostSlot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = &hostSlot{slots: make(chan struct{}, l.maxConcurrent)}
+		l.hosts[host] = slot
+	}
+	return slot
+}
+
+func hostOf(rawURL string) string {
+	parsedUrl, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsedUrl.Hostname()
+}
@@ -0,0 +1,217 @@
+// Package api serves the aggregator's operations as a versioned JSON REST
+// API. Every route lives under /v1 and needs an API key sent as a bearer
+// token; responses are JSON objects, lists are wrapped in a page and errors
+// are reported as {"error": "..."}.
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peeta98/blog-aggregator/internal/apikey"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	maxBodySize  = 1 << 20
+)
+
+type Server struct {
+	db       database.Store
+	conn     *sql.DB
+	newStore func(database.DBTX) database.Store
+	mux      *http.ServeMux
+}
+
+// New returns a server querying conn through the Store newStore builds,
+// which is also used to run the handlers that write several rows in a
+// transaction.
+func New(conn *sql.DB, newStore func(database.DBTX) database.Store) *Server {
+	s := &Server{
+		db:       newStore(conn),
+		conn:     conn,
+		newStore: newStore,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /v1/users/{user}", s.withUser(s.handleGetUser))
+
+	s.mux.HandleFunc("GET /v1/feeds", s.authenticated(s.handleListFeeds))
+	s.mux.HandleFunc("POST /v1/users/{user}/feeds", s.withUser(s.handleCreateFeed))
+
+	s.mux.HandleFunc("GET /v1/users/{user}/follows", s.withUser(s.handleListFollows))
+	s.mux.HandleFunc("POST /v1/users/{user}/follows", s.withUser(s.handleCreateFollow))
+	s.mux.HandleFunc("DELETE /v1/users/{user}/follows/{feed_id}", s.withUser(s.handleDeleteFollow))
+
+	s.mux.HandleFunc("GET /v1/users/{user}/posts", s.withUser(s.handleListPosts))
+	s.mux.HandleFunc("PUT /v1/users/{user}/posts/{post_id}/read", s.withUser(s.handleMarkRead))
+	s.mux.HandleFunc("DELETE /v1/users/{user}/posts/{post_id}/read", s.withUser(s.handleMarkUnread))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type userHandler func(http.ResponseWriter, *http.Request, database.User)
+
+// middlewareAuth resolves the user owning the API key sent in the
+// Authorization header, the way middlewareLoggedIn resolves the current user
+// of the CLI, before calling next.
+func (s *Server) middlewareAuth(next userHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(key) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator"`)
+			writeError(w, http.StatusUnauthorized, "missing API key")
+			return
+		}
+
+		keyHash := apikey.Hash(key)
+		user, err := s.db.GetUserByAPIKey(r.Context(), keyHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "invalid or revoked API key")
+			return
+		}
+		if err != nil {
+			writeDBError(w, err, "API key")
+			return
+		}
+
+		err = s.db.TouchAPIKey(r.Context(), database.TouchAPIKeyParams{
+			KeyHash:    keyHash,
+			LastUsedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("Couldn't record API key use: %v", err)
+		}
+		next(w, r, user)
+	}
+}
+
+// authenticated requires an API key for routes that aren't about a user.
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return s.middlewareAuth(func(w http.ResponseWriter, r *http.Request, _ database.User) {
+		next(w, r)
+	})
+}
+
+// withUser authenticates the request and checks that the {user} path
+// segment names the owner of the API key.
+func (s *Server) withUser(next userHandler) http.HandlerFunc {
+	return s.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if r.PathValue("user") != user.Name {
+			writeError(w, http.StatusForbidden, "API key doesn't belong to user "+r.PathValue("user"))
+			return
+		}
+		next(w, r, user)
+	})
+}
+
+// page is the response envelope of every list endpoint. NextOffset is only
+// set when there may be more items.
+type page[T any] struct {
+	Items      []T  `json:"items"`
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset"`
+}
+
+type pagination struct {
+	limit  int
+	offset int
+}
+
+func parsePagination(r *http.Request) (pagination, error) {
+	p := pagination{limit: defaultLimit}
+	query := r.URL.Query()
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return p, fmt.Errorf("limit must be a number between 1 and %d", maxLimit)
+		}
+		p.limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return p, errors.New("offset must be a positive number")
+		}
+		p.offset = offset
+	}
+	return p, nil
+}
+
+func newPage[T any](items []T, p pagination) page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	result := page[T]{
+		Items:  items,
+		Limit:  p.limit,
+		Offset: p.offset,
+	}
+	if len(items) == p.limit {
+		next := p.offset + p.limit
+		result.NextOffset = &next
+	}
+	return result
+}
+
+// paginate pages through items already loaded in memory.
+func paginate[T any](items []T, p pagination) page[T] {
+	if p.offset >= len(items) {
+		return newPage([]T{}, p)
+	}
+	items = items[p.offset:]
+	if len(items) > p.limit {
+		items = items[:p.limit]
+	}
+	return newPage(items, p)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Couldn't write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeDBError maps a query error to a response: missing rows are a 404 for
+// the named resource, duplicates a 409 and anything else a 500.
+func writeDBError(w http.ResponseWriter, err error, resource string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, resource+" not found")
+	case errors.Is(err, database.ErrConflict):
+		writeError(w, http.StatusConflict, resource+" already exists")
+	default:
+		log.Printf("Couldn't query %s: %v", resource, err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
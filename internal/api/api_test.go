@@ -0,0 +1,296 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/apikey"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/database/sqlite"
+	"github.com/pressly/goose/v3"
+)
+
+// testServer serves the API over a migrated SQLite database.
+type testServer struct {
+	*httptest.Server
+	db database.Store
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "gator.db") + "?_pragma=foreign_keys(1)&_time_format=sqlite"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, conn, os.DirFS("../../sql/sqlite/schema"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Up(context.Background()); err != nil {
+		t.Fatalf("couldn't apply migrations: %v", err)
+	}
+
+	newStore := func(db database.DBTX) database.Store { return sqlite.New(db) }
+	server := httptest.NewServer(New(conn, newStore))
+	t.Cleanup(server.Close)
+	return &testServer{Server: server, db: newStore(conn)}
+}
+
+// createUser creates a user with an API key and returns the key.
+func (s *testServer) createUser(t *testing.T, name string) (database.User, string) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	u, err := s.db.CreateUser(ctx, database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, display, err := apikey.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.db.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UserID:    u.ID,
+		Name:      "test",
+		Prefix:    display,
+		KeyHash:   apikey.Hash(key),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, key
+}
+
+// do sends a request with key as bearer token, unless it is empty, and
+// decodes the JSON response into out, unless it is nil.
+func (s *testServer) do(t *testing.T, method, path, key string, body any, out any) *http.Response {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, s.URL+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	res, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: couldn't decode response: %v", method, path, err)
+		}
+	}
+	return res
+}
+
+func (s *testServer) expect(t *testing.T, method, path, key string, body any, status int) {
+	t.Helper()
+	var errBody map[string]string
+	var out any
+	if status >= 400 {
+		out = &errBody
+	}
+	res := s.do(t, method, path, key, body, out)
+	if res.StatusCode != status {
+		t.Errorf("%s %s returned %d, want %d", method, path, res.StatusCode, status)
+	}
+	if status >= 400 && errBody["error"] == "" {
+		t.Errorf("%s %s returned no error message", method, path)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	s := newTestServer(t)
+	_, aliceKey := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+
+	for _, key := range []string{"", "gator_not-a-key"} {
+		var body map[string]string
+		res := s.do(t, http.MethodGet, "/v1/users/alice", key, nil, &body)
+		if res.StatusCode != http.StatusUnauthorized || body["error"] == "" {
+			t.Errorf("GET /v1/users/alice with key %q returned %d %v, want 401", key, res.StatusCode, body)
+		}
+		if res.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("GET /v1/users/alice with key %q returned no WWW-Authenticate header", key)
+		}
+	}
+
+	var got user
+	if res := s.do(t, http.MethodGet, "/v1/users/alice", aliceKey, nil, &got); res.StatusCode != http.StatusOK || got.Name != "alice" {
+		t.Errorf("GET /v1/users/alice returned %d %+v, want 200 and alice", res.StatusCode, got)
+	}
+	s.expect(t, http.MethodGet, "/v1/users/bob", aliceKey, nil, http.StatusForbidden)
+
+	// A key only gives access to its owner: users can't be listed or created.
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		res := s.do(t, method, "/v1/users", aliceKey, map[string]string{"name": "mallory"}, nil)
+		if res.StatusCode != http.StatusNotFound && res.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("%s /v1/users returned %d, want the route not to exist", method, res.StatusCode)
+		}
+	}
+	if _, err := s.db.GetUser(context.Background(), "mallory"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUser(mallory) returned %v, want no user created", err)
+	}
+}
+
+func TestFeedsAndFollows(t *testing.T) {
+	s := newTestServer(t)
+	_, aliceKey := s.createUser(t, "alice")
+	_, bobKey := s.createUser(t, "bob")
+
+	var created feed
+	res := s.do(t, http.MethodPost, "/v1/users/alice/feeds", aliceKey, map[string]string{"name": "Go Blog", "url": "https://go.dev/blog/feed.atom"}, &created)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("POST feeds returned %d, want 201", res.StatusCode)
+	}
+	if created.Name != "Go Blog" || created.URL != "https://go.dev/blog/feed.atom" {
+		t.Errorf("POST feeds returned %+v", created)
+	}
+
+	s.expect(t, http.MethodPost, "/v1/users/alice/feeds", aliceKey, map[string]string{"name": "Copy", "url": created.URL}, http.StatusConflict)
+	s.expect(t, http.MethodPost, "/v1/users/alice/feeds", aliceKey, map[string]string{"name": "Bad", "url": "ftp://example.com"}, http.StatusUnprocessableEntity)
+	s.expect(t, http.MethodPost, "/v1/users/alice/feeds", aliceKey, map[string]string{"name": "Bad", "link": "https://example.com"}, http.StatusBadRequest)
+
+	var feeds page[feed]
+	s.do(t, http.MethodGet, "/v1/feeds", bobKey, nil, &feeds)
+	if len(feeds.Items) != 1 || feeds.Items[0].ID != created.ID {
+		t.Errorf("GET /v1/feeds = %+v, want the created feed", feeds.Items)
+	}
+
+	// Adding a feed follows it.
+	var follows page[follow]
+	s.do(t, http.MethodGet, "/v1/users/alice/follows", aliceKey, nil, &follows)
+	if len(follows.Items) != 1 || follows.Items[0].FeedID != created.ID {
+		t.Errorf("GET alice's follows = %+v, want the created feed", follows.Items)
+	}
+
+	var followed follow
+	res = s.do(t, http.MethodPost, "/v1/users/bob/follows", bobKey, map[string]string{"feed_url": created.URL, "folder": "Go"}, &followed)
+	if res.StatusCode != http.StatusCreated || followed.FeedID != created.ID || followed.Folder != "Go" {
+		t.Errorf("POST bob's follows returned %d %+v", res.StatusCode, followed)
+	}
+	s.expect(t, http.MethodPost, "/v1/users/bob/follows", bobKey, map[string]string{"feed_url": created.URL}, http.StatusConflict)
+	s.expect(t, http.MethodPost, "/v1/users/bob/follows", bobKey, map[string]string{"feed_url": "https://example.com/missing"}, http.StatusNotFound)
+
+	followPath := "/v1/users/bob/follows/" + created.ID.String()
+	s.expect(t, http.MethodDelete, followPath, bobKey, nil, http.StatusNoContent)
+	s.expect(t, http.MethodDelete, followPath, bobKey, nil, http.StatusNotFound)
+	s.expect(t, http.MethodDelete, "/v1/users/bob/follows/not-a-uuid", bobKey, nil, http.StatusBadRequest)
+
+	s.do(t, http.MethodGet, "/v1/users/bob/follows", bobKey, nil, &follows)
+	if len(follows.Items) != 0 {
+		t.Errorf("GET bob's follows = %+v after unfollowing, want none", follows.Items)
+	}
+}
+
+func TestPosts(t *testing.T) {
+	s := newTestServer(t)
+	_, aliceKey := s.createUser(t, "alice")
+	var created feed
+	s.do(t, http.MethodPost, "/v1/users/alice/feeds", aliceKey, map[string]string{"name": "Blog", "url": "https://example.com/feed"}, &created)
+
+	day := func(n int) time.Time { return time.Date(2024, 3, n, 12, 0, 0, 0, time.UTC) }
+	ids := map[string]uuid.UUID{}
+	for i, title := range []string{"first", "second", "third"} {
+		p, err := s.db.UpsertPost(context.Background(), database.UpsertPostParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+			Title:       title,
+			Url:         "https://example.com/" + title,
+			PublishedAt: sql.NullTime{Time: day(i + 1), Valid: true},
+			FeedID:      created.ID,
+			Guid:        title,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[title] = p.ID
+	}
+
+	list := func(query string) page[post] {
+		t.Helper()
+		var result page[post]
+		if res := s.do(t, http.MethodGet, "/v1/users/alice/posts"+query, aliceKey, nil, &result); res.StatusCode != http.StatusOK {
+			t.Fatalf("GET posts%s returned %d, want 200", query, res.StatusCode)
+		}
+		return result
+	}
+	titles := func(p page[post]) []string {
+		var result []string
+		for _, item := range p.Items {
+			result = append(result, item.Title)
+		}
+		return result
+	}
+
+	first := list("?limit=2")
+	if got := titles(first); !reflect.DeepEqual(got, []string{"third", "second"}) {
+		t.Errorf("first page = %v, want [third second]", got)
+	}
+	if first.NextOffset == nil || *first.NextOffset != 2 {
+		t.Errorf("first page next_offset = %v, want 2", first.NextOffset)
+	}
+	last := list("?limit=2&offset=2")
+	if got := titles(last); !reflect.DeepEqual(got, []string{"first"}) || last.NextOffset != nil {
+		t.Errorf("last page = %v next_offset %v, want [first] and no next offset", got, last.NextOffset)
+	}
+
+	// Times with an offset compare in UTC: 13:00+01:00 is the noon of day 2.
+	if got := titles(list("?since=2024-03-02T13:00:00%2B01:00")); !reflect.DeepEqual(got, []string{"third", "second"}) {
+		t.Errorf("posts since day 2 = %v, want [third second]", got)
+	}
+	if got := titles(list("?until=2024-03-02T12:00:00Z&reverse=true")); !reflect.DeepEqual(got, []string{"first"}) {
+		t.Errorf("posts until day 2 = %v, want [first]", got)
+	}
+	for _, query := range []string{"?since=yesterday", "?limit=0", "?limit=101", "?offset=-1", "?sort=title", "?unread=maybe"} {
+		s.expect(t, http.MethodGet, "/v1/users/alice/posts"+query, aliceKey, nil, http.StatusBadRequest)
+	}
+
+	readPath := "/v1/users/alice/posts/" + ids["second"].String() + "/read"
+	s.expect(t, http.MethodPut, readPath, aliceKey, nil, http.StatusNoContent)
+	s.expect(t, http.MethodPut, readPath, aliceKey, nil, http.StatusNoContent)
+	s.expect(t, http.MethodPut, "/v1/users/alice/posts/"+uuid.NewString()+"/read", aliceKey, nil, http.StatusNotFound)
+	if got := titles(list("?unread=true")); !reflect.DeepEqual(got, []string{"third", "first"}) {
+		t.Errorf("unread posts = %v, want [third first]", got)
+	}
+	s.expect(t, http.MethodDelete, readPath, aliceKey, nil, http.StatusNoContent)
+	if got := titles(list("?unread=true")); len(got) != 3 {
+		t.Errorf("unread posts = %v after marking second unread, want all 3", got)
+	}
+	// Unreading is idempotent too, but like reading it only accepts the
+	// user's own posts.
+	s.expect(t, http.MethodDelete, readPath, aliceKey, nil, http.StatusNoContent)
+	s.expect(t, http.MethodDelete, "/v1/users/alice/posts/"+uuid.NewString()+"/read", aliceKey, nil, http.StatusNotFound)
+}
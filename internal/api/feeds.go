@@ -0,0 +1,240 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+type feed struct {
+	ID                   uuid.UUID  `json:"id"`
+	Name                 string     `json:"name"`
+	URL                  string     `json:"url"`
+	UserID               uuid.UUID  `json:"user_id"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+	LastFetchedAt        *time.Time `json:"last_fetched_at"`
+	NextFetchAt          *time.Time `json:"next_fetch_at"`
+	FetchIntervalSeconds *int32     `json:"fetch_interval_seconds"`
+	DisabledAt           *time.Time `json:"disabled_at"`
+}
+
+func newFeed(f database.Feed) feed {
+	result := feed{
+		ID:            f.ID,
+		Name:          f.Name,
+		URL:           f.Url,
+		UserID:        f.UserID,
+		CreatedAt:     f.CreatedAt,
+		UpdatedAt:     f.UpdatedAt,
+		LastFetchedAt: timeOrNil(f.LastFetchedAt),
+		NextFetchAt:   timeOrNil(f.NextFetchAt),
+		DisabledAt:    timeOrNil(f.DisabledAt),
+	}
+	if f.FetchIntervalSeconds.Valid {
+		result.FetchIntervalSeconds = &f.FetchIntervalSeconds.Int32
+	}
+	return result
+}
+
+type follow struct {
+	FeedID     uuid.UUID `json:"feed_id"`
+	FeedName   string    `json:"feed_name"`
+	FeedURL    string    `json:"feed_url"`
+	Folder     string    `json:"folder"`
+	Unread     int64     `json:"unread"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (s *Server) handleListFeeds(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feeds, err := s.db.GetFeeds(r.Context())
+	if err != nil {
+		writeDBError(w, err, "feeds")
+		return
+	}
+
+	items := make([]feed, 0, len(feeds))
+	for _, f := range feeds {
+		items = append(items, newFeed(f))
+	}
+	writeJSON(w, http.StatusOK, paginate(items, p))
+}
+
+// handleCreateFeed adds a feed owned by the user and follows it, like the
+// addfeed command but without discovery: the URL must be the feed itself.
+func (s *Server) handleCreateFeed(w http.ResponseWriter, r *http.Request, u database.User) {
+	var body struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	if !validURL(body.URL) {
+		writeError(w, http.StatusUnprocessableEntity, "url must be an absolute http or https URL")
+		return
+	}
+
+	tx, err := s.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeDBError(w, err, "feed")
+		return
+	}
+	defer tx.Rollback()
+	db := s.newStore(tx)
+
+	now := time.Now().UTC()
+	created, err := db.CreateFeed(r.Context(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      body.Name,
+		Url:       body.URL,
+		UserID:    u.ID,
+	})
+	if err != nil {
+		writeDBError(w, err, "feed")
+		return
+	}
+
+	_, err = db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    u.ID,
+		FeedID:    created.ID,
+	})
+	if err != nil {
+		writeDBError(w, err, "feed follow")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeDBError(w, err, "feed")
+		return
+	}
+	writeJSON(w, http.StatusCreated, newFeed(created))
+}
+
+func (s *Server) handleListFollows(w http.ResponseWriter, r *http.Request, u database.User) {
+	p, err := parsePagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(r.Context(), u.ID)
+	if err != nil {
+		writeDBError(w, err, "feed follows")
+		return
+	}
+
+	items := make([]follow, 0, len(follows))
+	for _, ff := range follows {
+		items = append(items, follow{
+			FeedID:     ff.FeedID,
+			FeedName:   ff.FeedName,
+			FeedURL:    ff.FeedUrl,
+			Folder:     ff.Folder.String,
+			Unread:     ff.UnreadCount,
+			FollowedAt: ff.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, paginate(items, p))
+}
+
+func (s *Server) handleCreateFollow(w http.ResponseWriter, r *http.Request, u database.User) {
+	var body struct {
+		FeedURL string `json:"feed_url"`
+		Folder  string `json:"folder"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if !validURL(body.FeedURL) {
+		writeError(w, http.StatusUnprocessableEntity, "feed_url must be an absolute http or https URL")
+		return
+	}
+
+	f, err := s.db.GetFeedByUrl(r.Context(), body.FeedURL)
+	if err != nil {
+		writeDBError(w, err, "feed")
+		return
+	}
+
+	now := time.Now().UTC()
+	created, err := s.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    u.ID,
+		FeedID:    f.ID,
+		Folder:    sql.NullString{String: body.Folder, Valid: body.Folder != ""},
+	})
+	if err != nil {
+		writeDBError(w, err, "feed follow")
+		return
+	}
+	writeJSON(w, http.StatusCreated, follow{
+		FeedID:     f.ID,
+		FeedName:   f.Name,
+		FeedURL:    f.Url,
+		Folder:     body.Folder,
+		FollowedAt: created.CreatedAt,
+	})
+}
+
+func (s *Server) handleDeleteFollow(w http.ResponseWriter, r *http.Request, u database.User) {
+	feedID, err := uuid.Parse(r.PathValue("feed_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid feed id")
+		return
+	}
+
+	_, err = s.db.GetFeedFollow(r.Context(), database.GetFeedFollowParams{
+		UserID: u.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		writeDBError(w, err, "feed follow")
+		return
+	}
+
+	err = s.db.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		UserID: u.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		writeDBError(w, err, "feed follow")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func timeOrNil(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
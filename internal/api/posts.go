@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+type post struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	FeedName    string     `json:"feed_name"`
+	PublishedAt *time.Time `json:"published_at"`
+	FetchedAt   time.Time  `json:"fetched_at"`
+	ReadAt      *time.Time `json:"read_at"`
+}
+
+// handleListPosts lists the posts of the feeds the user follows. It takes
+// the filters of the browse command as query parameters: feed (name or URL),
+// since and until (RFC 3339), unread, sort (published, fetched or feed) and
+// reverse.
+func (s *Server) handleListPosts(w http.ResponseWriter, r *http.Request, u database.User) {
+	p, err := parsePagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	params := database.ListPostsForUserParams{
+		UserID: u.ID,
+		Feed:   query.Get("feed"),
+		Sort:   database.SortPublished,
+		Limit:  int32(p.limit),
+		Offset: int32(p.offset),
+	}
+	if value := query.Get("sort"); value != "" {
+		params.Sort = database.PostSort(value)
+	}
+	switch params.Sort {
+	case database.SortPublished, database.SortFetched, database.SortFeed:
+	default:
+		writeError(w, http.StatusBadRequest, "sort must be published, fetched or feed")
+		return
+	}
+	for name, target := range map[string]*time.Time{"since": &params.Since, "until": &params.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, name+" must be an RFC 3339 time")
+				return
+			}
+			*target = t.UTC()
+		}
+	}
+	for name, target := range map[string]*bool{"unread": &params.UnreadOnly, "reverse": &params.Reverse} {
+		if value := query.Get(name); value != "" {
+			if *target, err = strconv.ParseBool(value); err != nil {
+				writeError(w, http.StatusBadRequest, name+" must be true or false")
+				return
+			}
+		}
+	}
+
+	posts, err := s.db.ListPostsForUser(r.Context(), params)
+	if err != nil {
+		writeDBError(w, err, "posts")
+		return
+	}
+
+	items := make([]post, 0, len(posts))
+	for _, row := range posts {
+		items = append(items, post{
+			ID:          row.ID,
+			Title:       row.Title,
+			URL:         row.Url,
+			Description: row.Description.String,
+			FeedID:      row.FeedID,
+			FeedName:    row.FeedName,
+			PublishedAt: timeOrNil(row.PublishedAt),
+			FetchedAt:   row.CreatedAt,
+			ReadAt:      timeOrNil(row.ReadAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, newPage(items, p))
+}
+
+// handleMarkRead marks a post as read. It is idempotent, posts already read
+// are left as is, but posts outside the user's feeds are a 404.
+func (s *Server) handleMarkRead(w http.ResponseWriter, r *http.Request, u database.User) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	rows, err := s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: u.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		writeDBError(w, err, "post")
+		return
+	}
+	if rows == 0 {
+		writeError(w, http.StatusNotFound, "post not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMarkUnread(w http.ResponseWriter, r *http.Request, u database.User) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+
+	// Unreading a post that isn't read is fine, so the count can't tell a
+	// foreign post apart: resolve it against the user's follows first.
+	if _, err := s.db.GetFollowedPostID(r.Context(), database.GetFollowedPostIDParams{
+		UserID: u.ID,
+		ID:     postID,
+	}); err != nil {
+		writeDBError(w, err, "post")
+		return
+	}
+
+	_, err = s.db.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{
+		UserID: u.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		writeDBError(w, err, "post")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+type user struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newUser(u database.User) user {
+	return user{
+		ID:        u.ID,
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request, u database.User) {
+	writeJSON(w, http.StatusOK, newUser(u))
+}
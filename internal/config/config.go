@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const configFileName = ".gatorconfig.json"
@@ -12,10 +13,47 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	DbUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	SessionToken    string `json:"session_token,omitempty"`
 }
 
-func (cfg *Config) SetUser(username string) error {
+// Database backends, selected by the scheme of db_url.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// Database returns the backend db_url selects and where to find the
+// database: postgres:// and postgresql:// URLs are PostgreSQL connection
+// strings and are returned as is, sqlite:// URLs name the file of an SQLite
+// database, e.g. sqlite://~/.gator.db.
+func (cfg Config) Database() (backend, dsn string, err error) {
+	scheme, rest, ok := strings.Cut(cfg.DbUrl, "://")
+	switch {
+	case !ok:
+		return "", "", fmt.Errorf("db_url %q has no scheme, it must start with postgres:// or sqlite://", cfg.DbUrl)
+	case scheme == "postgres" || scheme == "postgresql":
+		return Postgres, cfg.DbUrl, nil
+	case scheme == SQLite:
+		if rest == "" {
+			return "", "", fmt.Errorf("db_url %q doesn't name a database file", cfg.DbUrl)
+		}
+		if path, ok := strings.CutPrefix(rest, "~/"); ok {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", "", fmt.Errorf("couldn't get Home directory: %v", err)
+			}
+			rest = filepath.Join(homeDir, path)
+		}
+		return SQLite, rest, nil
+	default:
+		return "", "", fmt.Errorf("unsupported db_url scheme %q, use postgres:// or sqlite://", scheme)
+	}
+}
+
+// SetSession records the logged in user and the session token proving it.
+func (cfg *Config) SetSession(username, token string) error {
 	cfg.CurrentUserName = username
+	cfg.SessionToken = token
 	return write(*cfg)
 }
 
@@ -55,11 +93,15 @@ func write(cfg Config) error {
 		return err
 	}
 
-	file, err := os.Create(configPath)
+	// The session token is a credential, keep it away from other users
+	file, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return fmt.Errorf("couldn't create named file %s: %v", configPath, err)
 	}
 	defer file.Close()
+	if err = file.Chmod(0o600); err != nil {
+		return fmt.Errorf("couldn't restrict permissions of %s: %v", configPath, err)
+	}
 
 	encoder := json.NewEncoder(file)
 	if err = encoder.Encode(cfg); err != nil {
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,12 +15,12 @@ import (
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    RETURNING id, created_at, updated_at, user_id, feed_id, folder
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -33,6 +34,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -41,6 +43,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -52,6 +55,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Folder,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -60,6 +64,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -81,11 +86,42 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 	return err
 }
 
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, folder FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Folder,
+	)
+	return i, err
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT
-    f_f.id, f_f.created_at, f_f.updated_at, f_f.user_id, f_f.feed_id,
+    f_f.id, f_f.created_at, f_f.updated_at, f_f.user_id, f_f.feed_id, f_f.folder,
     u.name AS user_name,
-    f.name AS feed_name
+    f.name AS feed_name,
+    f.url AS feed_url,
+    f.site_url AS feed_site_url,
+    (
+        SELECT COUNT(*) FROM posts p
+        LEFT JOIN post_reads p_r ON p_r.post_id = p.id AND p_r.user_id = f_f.user_id
+        WHERE p.feed_id = f_f.feed_id AND p_r.read_at IS NULL
+    ) AS unread_count
 FROM feed_follows f_f
 INNER JOIN feeds f ON f_f.feed_id = f.id
 INNER JOIN users u ON f_f.user_id = u.id
@@ -93,13 +129,17 @@ WHERE f_f.user_id = $1
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	UserName  string
-	FeedName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	Folder      sql.NullString
+	UserName    string
+	FeedName    string
+	FeedUrl     string
+	FeedSiteUrl sql.NullString
+	UnreadCount int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -117,8 +157,12 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Folder,
 			&i.UserName,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedSiteUrl,
+			&i.UnreadCount,
 		); err != nil {
 			return nil, err
 		}
@@ -7,11 +7,93 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimFeed = `-- name: ClaimFeed :one
+UPDATE feeds
+SET last_fetched_at = $1::timestamp,
+    updated_at = $1::timestamp,
+    lease_owner = $2::text,
+    lease_expires_at = $1::timestamp + $3::integer * INTERVAL '1 second'
+WHERE id = $4
+    AND (lease_expires_at IS NULL OR lease_expires_at <= $1::timestamp OR lease_owner = $2::text)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url
+`
+
+type ClaimFeedParams struct {
+	Now          time.Time
+	LeaseOwner   string
+	LeaseSeconds int32
+	ID           uuid.UUID
+}
+
+// Leases a feed to the caller for a fetch outside of the schedule. No row is
+// returned while another aggregator holds the lease.
+func (q *Queries) ClaimFeed(ctx context.Context, arg ClaimFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimFeed,
+		arg.Now,
+		arg.LeaseOwner,
+		arg.LeaseSeconds,
+		arg.ID,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const countFailingFeeds = `-- name: CountFailingFeeds :one
+SELECT COUNT(*) FROM feeds
+WHERE disabled_at IS NULL AND consecutive_failures > 0
+`
+
+// Counts the enabled feeds whose last fetch failed.
+func (q *Queries) CountFailingFeeds(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFailingFeeds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOverdueFeeds = `-- name: CountOverdueFeeds :one
+SELECT COUNT(*) FROM feeds
+WHERE disabled_at IS NULL
+  AND (next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp)
+  AND (lease_expires_at IS NULL OR lease_expires_at <= $1::timestamp)
+`
+
+// Counts the enabled feeds that are due for a fetch and that no aggregator
+// is fetching.
+func (q *Queries) CountOverdueFeeds(ctx context.Context, now time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOverdueFeeds, now)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES (
@@ -22,7 +104,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url
 `
 
 type CreateFeedParams struct {
@@ -52,12 +134,134 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
 
+const disableFeed = `-- name: DisableFeed :exec
+UPDATE feeds
+SET disabled_at = $1::timestamp,
+    lease_owner = NULL,
+    lease_expires_at = NULL,
+    updated_at = $1::timestamp
+WHERE id = $2
+`
+
+type DisableFeedParams struct {
+	Now time.Time
+	ID  uuid.UUID
+}
+
+func (q *Queries) DisableFeed(ctx context.Context, arg DisableFeedParams) error {
+	_, err := q.db.ExecContext(ctx, disableFeed, arg.Now, arg.ID)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET disabled_at = NULL,
+    consecutive_failures = 0,
+    next_fetch_at = NULL,
+    updated_at = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url
+`
+
+type EnableFeedParams struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) EnableFeed(ctx context.Context, arg EnableFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, arg.ID, arg.UpdatedAt)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const getFailingFeeds = `-- name: GetFailingFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at DESC NULLS LAST, consecutive_failures DESC
+`
+
+func (q *Queries) GetFailingFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFailingFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.LastStatusCode,
+			&i.DisabledAt,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.SiteUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url FROM feeds
 WHERE url = $1
 `
 
@@ -72,12 +276,24 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -97,6 +313,18 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.LastStatusCode,
+			&i.DisabledAt,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.SiteUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -111,14 +339,107 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = $1::timestamp,
+    updated_at = $1::timestamp,
+    lease_owner = $2::text,
+    lease_expires_at = $1::timestamp + $3::integer * INTERVAL '1 second'
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp)
+      AND (lease_expires_at IS NULL OR lease_expires_at <= $1::timestamp)
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url
+`
+
+type GetNextFeedsToFetchParams struct {
+	Now          time.Time
+	LeaseOwner   string
+	LeaseSeconds int32
+	FeedLimit    int32
+}
+
+// Claims the feeds that have been due the longest by leasing them to the
+// caller, so that other aggregators skip them until the lease is released
+// or expires.
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, arg GetNextFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch,
+		arg.Now,
+		arg.LeaseOwner,
+		arg.LeaseSeconds,
+		arg.FeedLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.LastStatusCode,
+			&i.DisabledAt,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.SiteUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :one
+UPDATE feeds
+SET last_error = $1,
+    last_status_code = $2,
+    last_error_at = $3::timestamp,
+    consecutive_failures = consecutive_failures + 1,
+    updated_at = $3::timestamp
+WHERE id = $4
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+type RecordFeedFailureParams struct {
+	LastError      sql.NullString
+	LastStatusCode sql.NullInt32
+	Now            time.Time
+	ID             uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailure,
+		arg.LastError,
+		arg.LastStatusCode,
+		arg.Now,
+		arg.ID,
+	)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -128,20 +449,77 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :one
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
 UPDATE feeds
-SET last_fetched_at = NOW(),
-    updated_at = NOW()
+SET last_status_code = $2,
+    consecutive_failures = 0,
+    updated_at = $3
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+type RecordFeedSuccessParams struct {
+	ID             uuid.UUID
+	LastStatusCode sql.NullInt32
+	UpdatedAt      time.Time
+}
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, arg.ID, arg.LastStatusCode, arg.UpdatedAt)
+	return err
+}
+
+const releaseFeedLease = `-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2
+`
+
+type ReleaseFeedLeaseParams struct {
+	ID         uuid.UUID
+	LeaseOwner sql.NullString
+}
+
+// Gives up the lease on a feed without scheduling its next fetch, so that
+// any aggregator can fetch it again right away.
+func (q *Queries) ReleaseFeedLease(ctx context.Context, arg ReleaseFeedLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, arg.ID, arg.LeaseOwner)
+	return err
+}
+
+const updateFeedFetchInterval = `-- name: UpdateFeedFetchInterval :one
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+    next_fetch_at = NULL,
+    updated_at = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url
+`
+
+type UpdateFeedFetchIntervalParams struct {
+	ID                   uuid.UUID
+	FetchIntervalSeconds sql.NullInt32
+	UpdatedAt            time.Time
+}
+
+func (q *Queries) UpdateFeedFetchInterval(ctx context.Context, arg UpdateFeedFetchIntervalParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedFetchInterval, arg.ID, arg.FetchIntervalSeconds, arg.UpdatedAt)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -151,6 +529,84 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
+
+const updateFeedHTTPCache = `-- name: UpdateFeedHTTPCache :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = $4
+WHERE id = $1
+`
+
+type UpdateFeedHTTPCacheParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+	UpdatedAt    time.Time
+}
+
+func (q *Queries) UpdateFeedHTTPCache(ctx context.Context, arg UpdateFeedHTTPCacheParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedHTTPCache,
+		arg.ID,
+		arg.Etag,
+		arg.LastModified,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const updateFeedNextFetch = `-- name: UpdateFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = $2,
+    lease_owner = NULL,
+    lease_expires_at = NULL,
+    updated_at = $3
+WHERE id = $1
+`
+
+type UpdateFeedNextFetchParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+	UpdatedAt   time.Time
+}
+
+// Schedules the next fetch of a feed and releases the lease on it.
+func (q *Queries) UpdateFeedNextFetch(ctx context.Context, arg UpdateFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedNextFetch, arg.ID, arg.NextFetchAt, arg.UpdatedAt)
+	return err
+}
+
+const updateFeedSiteURL = `-- name: UpdateFeedSiteURL :exec
+UPDATE feeds
+SET site_url = $2,
+    updated_at = $3
+WHERE id = $1
+`
+
+type UpdateFeedSiteURLParams struct {
+	ID        uuid.UUID
+	SiteUrl   sql.NullString
+	UpdatedAt time.Time
+}
+
+// Records the address of the website a feed belongs to, as its document
+// links to it.
+func (q *Queries) UpdateFeedSiteURL(ctx context.Context, arg UpdateFeedSiteURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSiteURL, arg.ID, arg.SiteUrl, arg.UpdatedAt)
+	return err
+}
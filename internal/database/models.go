@@ -11,14 +11,37 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	LastError            sql.NullString
+	LastErrorAt          sql.NullTime
+	ConsecutiveFailures  int32
+	LastStatusCode       sql.NullInt32
+	DisabledAt           sql.NullTime
+	LeaseOwner           sql.NullString
+	LeaseExpiresAt       sql.NullTime
+	SiteUrl              sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,22 +50,53 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Guid         string
+	Content      sql.NullString
+	SearchVector interface{}
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	UserID      uuid.UUID
+	PostID      uuid.UUID
+	StarredAt   time.Time
 	Title       string
 	Url         string
 	Description sql.NullString
 	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	FetchedAt   time.Time
+	FeedName    string
 }
 
-type User struct {
+type Session struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ExpiresAt time.Time
+	UserID    uuid.UUID
+	TokenHash string
+}
+
+type User struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	PasswordHash sql.NullString
 }
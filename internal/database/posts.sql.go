@@ -13,93 +13,122 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const getFollowedPostID = `-- name: GetFollowedPostID :one
+SELECT p.id FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = $1 AND p.id = $2
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+type GetFollowedPostIDParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Title,
-		&i.Url,
-		&i.Description,
-		&i.PublishedAt,
-		&i.FeedID,
-	)
-	return i, err
+// Finds a post among the feeds the user follows.
+func (q *Queries) GetFollowedPostID(ctx context.Context, arg GetFollowedPostIDParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getFollowedPostID, arg.UserID, arg.ID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, f.name AS feed_name FROM posts p
-JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
-JOIN feeds f ON f.id = p.feed_id
-WHERE f_f.user_id = $1
-ORDER BY p.published_at DESC
+const getRecentPostDates = `-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
 LIMIT $2
 `
 
-type GetPostsForUserParams struct {
-	UserID uuid.UUID
+type GetRecentPostDatesParams struct {
+	FeedID uuid.UUID
 	Limit  int32
 }
 
-type GetPostsForUserRow struct {
+func (q *Queries) GetRecentPostDates(ctx context.Context, arg GetRecentPostDatesParams) ([]sql.NullTime, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostDates, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullTime
+	for rows.Next() {
+		var published_at sql.NullTime
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT p.id, p.title, p.url, p.published_at, f.name AS feed_name, f.url AS feed_url,
+    ts_rank(p.search_vector, tsq) AS rank,
+    ts_headline('english', coalesce(p.description, p.content, ''), tsq,
+        'StartSel=**, StopSel=**, MaxWords=30, MinWords=10, MaxFragments=2')::text AS snippet
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+JOIN feeds f ON f.id = p.feed_id
+CROSS JOIN websearch_to_tsquery('english', $1) tsq
+WHERE f_f.user_id = $2
+    AND p.search_vector @@ tsq
+    AND ($3::text IS NULL OR f.url = $3 OR f.name = $3)
+    AND ($4::timestamp IS NULL OR p.published_at >= $4)
+ORDER BY rank DESC, p.published_at DESC NULLS LAST
+LIMIT $5
+`
+
+type SearchPostsForUserParams struct {
+	Query     string
+	UserID    uuid.UUID
+	Feed      sql.NullString
+	Since     sql.NullTime
+	PostLimit int32
+}
+
+type SearchPostsForUserRow struct {
 	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
 	Title       string
 	Url         string
-	Description sql.NullString
 	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
 	FeedName    string
+	FeedUrl     string
+	Rank        float32
+	Snippet     string
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+// Full-text search over the posts of feeds the user follows, best matches
+// first. The query uses web search syntax: quoted phrases, "or" and -exclude.
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.Feed,
+		arg.Since,
+		arg.PostLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetPostsForUserRow
+	var items []SearchPostsForUserRow
 	for rows.Next() {
-		var i GetPostsForUserRow
+		var i SearchPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.Title,
 			&i.Url,
-			&i.Description,
 			&i.PublishedAt,
-			&i.FeedID,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -113,3 +142,77 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    published_at = COALESCE(posts.published_at, EXCLUDED.published_at),
+    updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR posts.content IS DISTINCT FROM EXCLUDED.content
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content
+`
+
+type UpsertPostParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Guid        string
+	Content     sql.NullString
+}
+
+type UpsertPostRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Guid        string
+	Content     sql.NullString
+}
+
+// Inserts a new post or refreshes an existing one with the same GUID. No row
+// is returned when the stored post is already up to date.
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.Content,
+	)
+	var i UpsertPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.Content,
+	)
+	return i, err
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const apiKeyColumns = `id, created_at, user_id, name, prefix, key_hash, last_used_at, revoked_at`
+
+func scanAPIKey(row scanner) (database.ApiKey, error) {
+	var i database.ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createAPIKey = `INSERT INTO api_keys (id, created_at, user_id, name, prefix, key_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING ` + apiKeyColumns
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error) {
+	i, err := scanAPIKey(q.db.QueryRowContext(ctx, createAPIKey,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+	))
+	return i, conflict(err)
+}
+
+const getAPIKeysForUser = `SELECT ` + apiKeyColumns + ` FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at`
+
+func (q *Queries) GetAPIKeysForUser(ctx context.Context, userID uuid.UUID) ([]database.ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, getAPIKeysForUser, userID)
+	return collect(rows, err, scanAPIKey)
+}
+
+const getUserByAPIKey = `SELECT ` + userColumns + ` FROM users
+JOIN api_keys ON api_keys.user_id = users.id
+WHERE api_keys.key_hash = $1 AND api_keys.revoked_at IS NULL`
+
+func (q *Queries) GetUserByAPIKey(ctx context.Context, keyHash string) (database.User, error) {
+	return scanUser(q.db.QueryRowContext(ctx, getUserByAPIKey, keyHash))
+}
+
+const revokeAPIKey = `UPDATE api_keys
+SET revoked_at = $3
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg database.RevokeAPIKeyParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, revokeAPIKey, arg.ID, arg.UserID, arg.RevokedAt))
+}
+
+const touchAPIKey = `UPDATE api_keys
+SET last_used_at = $2
+WHERE key_hash = $1`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, arg database.TouchAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, touchAPIKey, arg.KeyHash, arg.LastUsedAt)
+	return err
+}
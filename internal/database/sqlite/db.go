@@ -0,0 +1,115 @@
+// Package sqlite implements database.Store on SQLite, for installs that
+// don't want to run a PostgreSQL server. The queries mirror the ones sqlc
+// generates from sql/queries and return the same types; only the SQL
+// differs where the dialects do: there are no casts and no tsvector, so
+// search uses FTS5.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/peeta98/blog-aggregator/internal/database"
+	driver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type Queries struct {
+	db database.DBTX
+}
+
+var _ database.Store = (*Queries)(nil)
+
+func New(db database.DBTX) *Queries {
+	return &Queries{db: utcDB{db}}
+}
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return New(tx)
+}
+
+// utcDB converts every time argument to UTC before passing it on. SQLite
+// stores times as text, so they only compare correctly when they are all in
+// the same location.
+type utcDB struct {
+	db database.DBTX
+}
+
+func (u utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return u.db.ExecContext(ctx, query, utc(args)...)
+}
+
+func (u utcDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return u.db.PrepareContext(ctx, query)
+}
+
+func (u utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return u.db.QueryContext(ctx, query, utc(args)...)
+}
+
+func (u utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return u.db.QueryRowContext(ctx, query, utc(args)...)
+}
+
+func utc(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case sql.NullTime:
+			v.Time = v.Time.UTC()
+			args[i] = v
+		}
+	}
+	return args
+}
+
+// conflict wraps err with database.ErrConflict if it's a unique or primary
+// key violation.
+func conflict(err error) error {
+	var sqliteErr *driver.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return fmt.Errorf("%w: %w", database.ErrConflict, err)
+		}
+	}
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// collect scans every row with scan, the way the sqlc :many queries do.
+func collect[T any](rows *sql.Rows, err error, scan func(scanner) (T, error)) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []T
+	for rows.Next() {
+		i, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const feedFollowColumns = `id, created_at, updated_at, user_id, feed_id, folder`
+
+// SQLite doesn't allow an INSERT in a WITH clause, so the follow is inserted
+// first and read back with the names joined in.
+const createFeedFollow = `INSERT INTO feed_follows (` + feedFollowColumns + `)
+VALUES ($1, $2, $3, $4, $5, $6)`
+
+const getCreatedFeedFollow = `SELECT
+    f_f.id, f_f.created_at, f_f.updated_at, f_f.user_id, f_f.feed_id, f_f.folder,
+    feeds.name AS feed_name,
+    users.name AS user_name
+FROM feed_follows f_f
+INNER JOIN feeds ON f_f.feed_id = feeds.id
+INNER JOIN users ON f_f.user_id = users.id
+WHERE f_f.id = $1`
+
+func (q *Queries) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	var i database.CreateFeedFollowRow
+	_, err := q.db.ExecContext(ctx, createFeedFollow,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+		arg.Folder,
+	)
+	if err != nil {
+		return i, conflict(err)
+	}
+
+	row := q.db.QueryRowContext(ctx, getCreatedFeedFollow, arg.ID)
+	err = row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Folder,
+		&i.FeedName,
+		&i.UserName,
+	)
+	return i, err
+}
+
+const deleteFeedFollow = `DELETE FROM feed_follows
+WHERE feed_id = $1 AND user_id = $2`
+
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.FeedID, arg.UserID)
+	return err
+}
+
+const getFeedFollow = `SELECT ` + feedFollowColumns + ` FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2`
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg database.GetFeedFollowParams) (database.FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i database.FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Folder,
+	)
+	return i, err
+}
+
+const getFeedFollowsForUser = `SELECT
+    f_f.id, f_f.created_at, f_f.updated_at, f_f.user_id, f_f.feed_id, f_f.folder,
+    u.name AS user_name,
+    f.name AS feed_name,
+    f.url AS feed_url,
+    f.site_url AS feed_site_url,
+    (
+        SELECT COUNT(*) FROM posts p
+        LEFT JOIN post_reads p_r ON p_r.post_id = p.id AND p_r.user_id = f_f.user_id
+        WHERE p.feed_id = f_f.feed_id AND p_r.read_at IS NULL
+    ) AS unread_count
+FROM feed_follows f_f
+INNER JOIN feeds f ON f_f.feed_id = f.id
+INNER JOIN users u ON f_f.user_id = u.id
+WHERE f_f.user_id = $1`
+
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
+	return collect(rows, err, func(row scanner) (database.GetFeedFollowsForUserRow, error) {
+		var i database.GetFeedFollowsForUserRow
+		err := row.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+			&i.Folder,
+			&i.UserName,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedSiteUrl,
+			&i.UnreadCount,
+		)
+		return i, err
+	})
+}
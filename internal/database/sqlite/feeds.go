@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const feedColumns = `id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, last_error, last_error_at, consecutive_failures, last_status_code, disabled_at, lease_owner, lease_expires_at, site_url`
+
+func scanFeed(row scanner) (database.Feed, error) {
+	var i database.Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const claimFeed = `UPDATE feeds
+SET last_fetched_at = $3,
+    updated_at = $3,
+    lease_owner = $2,
+    lease_expires_at = $4
+WHERE id = $1
+    AND (lease_expires_at IS NULL OR lease_expires_at <= $3 OR lease_owner = $2)
+RETURNING ` + feedColumns
+
+func (q *Queries) ClaimFeed(ctx context.Context, arg database.ClaimFeedParams) (database.Feed, error) {
+	return scanFeed(q.db.QueryRowContext(ctx, claimFeed,
+		arg.ID,
+		arg.LeaseOwner,
+		arg.Now,
+		arg.Now.Add(time.Duration(arg.LeaseSeconds)*time.Second),
+	))
+}
+
+const countFailingFeeds = `SELECT COUNT(*) FROM feeds
+WHERE disabled_at IS NULL AND consecutive_failures > 0`
+
+func (q *Queries) CountFailingFeeds(ctx context.Context) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countFailingFeeds).Scan(&count)
+	return count, err
+}
+
+const countOverdueFeeds = `SELECT COUNT(*) FROM feeds
+WHERE disabled_at IS NULL
+  AND (next_fetch_at IS NULL OR next_fetch_at <= $1)
+  AND (lease_expires_at IS NULL OR lease_expires_at <= $1)`
+
+func (q *Queries) CountOverdueFeeds(ctx context.Context, now time.Time) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countOverdueFeeds, now).Scan(&count)
+	return count, err
+}
+
+const createFeed = `INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING ` + feedColumns
+
+func (q *Queries) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	i, err := scanFeed(q.db.QueryRowContext(ctx, createFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+	))
+	return i, conflict(err)
+}
+
+const disableFeed = `UPDATE feeds
+SET disabled_at = $2,
+    lease_owner = NULL,
+    lease_expires_at = NULL,
+    updated_at = $2
+WHERE id = $1`
+
+func (q *Queries) DisableFeed(ctx context.Context, arg database.DisableFeedParams) error {
+	_, err := q.db.ExecContext(ctx, disableFeed, arg.ID, arg.Now)
+	return err
+}
+
+const enableFeed = `UPDATE feeds
+SET disabled_at = NULL,
+    consecutive_failures = 0,
+    next_fetch_at = NULL,
+    updated_at = $2
+WHERE id = $1
+RETURNING ` + feedColumns
+
+func (q *Queries) EnableFeed(ctx context.Context, arg database.EnableFeedParams) (database.Feed, error) {
+	return scanFeed(q.db.QueryRowContext(ctx, enableFeed, arg.ID, arg.UpdatedAt))
+}
+
+const getFailingFeeds = `SELECT ` + feedColumns + ` FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at DESC NULLS LAST, consecutive_failures DESC`
+
+func (q *Queries) GetFailingFeeds(ctx context.Context) ([]database.Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFailingFeeds)
+	return collect(rows, err, scanFeed)
+}
+
+const getFeedByUrl = `SELECT ` + feedColumns + ` FROM feeds
+WHERE url = $1`
+
+func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	return scanFeed(q.db.QueryRowContext(ctx, getFeedByUrl, url))
+}
+
+const getFeeds = `SELECT ` + feedColumns + ` FROM feeds`
+
+func (q *Queries) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeeds)
+	return collect(rows, err, scanFeed)
+}
+
+// SQLite has a single writer, so unlike on PostgreSQL there are no locked
+// rows for concurrent aggregators to skip: the lease is all they need.
+const getNextFeedsToFetch = `UPDATE feeds
+SET last_fetched_at = $3,
+    updated_at = $3,
+    lease_owner = $2,
+    lease_expires_at = $4
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= $3)
+      AND (lease_expires_at IS NULL OR lease_expires_at <= $3)
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT $1
+)
+RETURNING ` + feedColumns
+
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, arg database.GetNextFeedsToFetchParams) ([]database.Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch,
+		arg.FeedLimit,
+		arg.LeaseOwner,
+		arg.Now,
+		arg.Now.Add(time.Duration(arg.LeaseSeconds)*time.Second),
+	)
+	return collect(rows, err, scanFeed)
+}
+
+const recordFeedFailure = `UPDATE feeds
+SET last_error = $2,
+    last_status_code = $3,
+    last_error_at = $4,
+    consecutive_failures = consecutive_failures + 1,
+    updated_at = $4
+WHERE id = $1
+RETURNING ` + feedColumns
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg database.RecordFeedFailureParams) (database.Feed, error) {
+	return scanFeed(q.db.QueryRowContext(ctx, recordFeedFailure,
+		arg.ID,
+		arg.LastError,
+		arg.LastStatusCode,
+		arg.Now,
+	))
+}
+
+const recordFeedSuccess = `UPDATE feeds
+SET last_status_code = $2,
+    consecutive_failures = 0,
+    updated_at = $3
+WHERE id = $1`
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg database.RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, arg.ID, arg.LastStatusCode, arg.UpdatedAt)
+	return err
+}
+
+const releaseFeedLease = `UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2`
+
+func (q *Queries) ReleaseFeedLease(ctx context.Context, arg database.ReleaseFeedLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, arg.ID, arg.LeaseOwner)
+	return err
+}
+
+const updateFeedFetchInterval = `UPDATE feeds
+SET fetch_interval_seconds = $2,
+    next_fetch_at = NULL,
+    updated_at = $3
+WHERE id = $1
+RETURNING ` + feedColumns
+
+func (q *Queries) UpdateFeedFetchInterval(ctx context.Context, arg database.UpdateFeedFetchIntervalParams) (database.Feed, error) {
+	return scanFeed(q.db.QueryRowContext(ctx, updateFeedFetchInterval, arg.ID, arg.FetchIntervalSeconds, arg.UpdatedAt))
+}
+
+const updateFeedHTTPCache = `UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = $4
+WHERE id = $1`
+
+func (q *Queries) UpdateFeedHTTPCache(ctx context.Context, arg database.UpdateFeedHTTPCacheParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedHTTPCache, arg.ID, arg.Etag, arg.LastModified, arg.UpdatedAt)
+	return err
+}
+
+const updateFeedNextFetch = `UPDATE feeds
+SET next_fetch_at = $2,
+    lease_owner = NULL,
+    lease_expires_at = NULL,
+    updated_at = $3
+WHERE id = $1`
+
+func (q *Queries) UpdateFeedNextFetch(ctx context.Context, arg database.UpdateFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedNextFetch, arg.ID, arg.NextFetchAt, arg.UpdatedAt)
+	return err
+}
+
+const updateFeedSiteURL = `UPDATE feeds
+SET site_url = $2,
+    updated_at = $3
+WHERE id = $1`
+
+func (q *Queries) UpdateFeedSiteURL(ctx context.Context, arg database.UpdateFeedSiteURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSiteURL, arg.ID, arg.SiteUrl, arg.UpdatedAt)
+	return err
+}
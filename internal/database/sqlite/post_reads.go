@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+// SQLite only tells the ON CONFLICT clause of an INSERT ... SELECT apart
+// from a join constraint when the SELECT has a WHERE clause, which all of
+// these have.
+
+const markAllPostsRead = `INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, $1
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = $2
+ON CONFLICT (user_id, post_id) DO NOTHING`
+
+func (q *Queries) MarkAllPostsRead(ctx context.Context, arg database.MarkAllPostsReadParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, markAllPostsRead, arg.ReadAt, arg.UserID))
+}
+
+const markFeedPostsRead = `INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, $1
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = $2 AND p.feed_id = $3
+ON CONFLICT (user_id, post_id) DO NOTHING`
+
+func (q *Queries) MarkFeedPostsRead(ctx context.Context, arg database.MarkFeedPostsReadParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, markFeedPostsRead, arg.ReadAt, arg.UserID, arg.FeedID))
+}
+
+const markPostRead = `INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, $1
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = $2 AND p.id = $3
+ON CONFLICT (user_id, post_id) DO UPDATE SET read_at = post_reads.read_at`
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, markPostRead, arg.ReadAt, arg.UserID, arg.PostID))
+}
+
+const markPostUnread = `DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2`
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, markPostUnread, arg.UserID, arg.PostID))
+}
+
+const markPostsReadBefore = `INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, $1
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = $2 AND p.published_at < $3
+ON CONFLICT (user_id, post_id) DO NOTHING`
+
+func (q *Queries) MarkPostsReadBefore(ctx context.Context, arg database.MarkPostsReadBeforeParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, markPostsReadBefore, arg.ReadAt, arg.UserID, arg.PublishedBefore))
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const getStarredPostsForUser = `SELECT p_s.post_id, p_s.title, p_s.url, p_s.description, p_s.published_at, p_s.fetched_at, p_s.feed_name, p_r.read_at FROM post_stars p_s
+LEFT JOIN post_reads p_r ON p_r.post_id = p_s.post_id AND p_r.user_id = p_s.user_id
+WHERE p_s.user_id = $1
+ORDER BY p_s.starred_at DESC`
+
+func (q *Queries) GetStarredPostsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetStarredPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStarredPostsForUser, userID)
+	return collect(rows, err, func(row scanner) (database.GetStarredPostsForUserRow, error) {
+		var i database.GetStarredPostsForUserRow
+		err := row.Scan(
+			&i.PostID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FetchedAt,
+			&i.FeedName,
+			&i.ReadAt,
+		)
+		return i, err
+	})
+}
+
+const starPost = `INSERT INTO post_stars (user_id, post_id, starred_at, title, url, description, published_at, fetched_at, feed_name)
+SELECT f_f.user_id, p.id, $1, p.title, p.url, p.description, p.published_at, p.created_at, f.name
+FROM posts p
+JOIN feeds f ON f.id = p.feed_id
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id AND f_f.user_id = $2
+WHERE p.id = $3
+ON CONFLICT (user_id, post_id) DO UPDATE SET starred_at = post_stars.starred_at`
+
+func (q *Queries) StarPost(ctx context.Context, arg database.StarPostParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, starPost, arg.StarredAt, arg.UserID, arg.PostID))
+}
+
+const unstarPost = `DELETE FROM post_stars
+WHERE user_id = $1 AND post_id = $2`
+
+func (q *Queries) UnstarPost(ctx context.Context, arg database.UnstarPostParams) (int64, error) {
+	return rowsAffected(q.db.ExecContext(ctx, unstarPost, arg.UserID, arg.PostID))
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const getFollowedPostID = `SELECT p.id FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = $1 AND p.id = $2`
+
+// Finds a post among the feeds the user follows.
+func (q *Queries) GetFollowedPostID(ctx context.Context, arg database.GetFollowedPostIDParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getFollowedPostID, arg.UserID, arg.ID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getRecentPostDates = `SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2`
+
+func (q *Queries) GetRecentPostDates(ctx context.Context, arg database.GetRecentPostDatesParams) ([]sql.NullTime, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostDates, arg.FeedID, arg.Limit)
+	return collect(rows, err, func(row scanner) (sql.NullTime, error) {
+		var published_at sql.NullTime
+		err := row.Scan(&published_at)
+		return published_at, err
+	})
+}
+
+// ListPostsForUser builds the same query as the PostgreSQL implementation,
+// whose SQL SQLite understands as is.
+func (q *Queries) ListPostsForUser(ctx context.Context, arg database.ListPostsForUserParams) ([]database.ListPostsForUserRow, error) {
+	return database.New(q.db).ListPostsForUser(ctx, arg)
+}
+
+// Inserts a new post or refreshes an existing one with the same GUID. No row
+// is returned when the stored post is already up to date.
+const upsertPost = `INSERT INTO posts AS p (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    content = excluded.content,
+    published_at = COALESCE(p.published_at, excluded.published_at),
+    updated_at = excluded.updated_at
+WHERE p.title IS NOT excluded.title
+    OR p.url IS NOT excluded.url
+    OR p.description IS NOT excluded.description
+    OR p.content IS NOT excluded.content
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content`
+
+func (q *Queries) UpsertPost(ctx context.Context, arg database.UpsertPostParams) (database.UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.Content,
+	)
+	var i database.UpsertPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.Content,
+	)
+	return i, err
+}
@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+// Full-text search over the posts of feeds the user follows, best matches
+// first. bm25 weighs the columns like the tsvector weights on PostgreSQL:
+// title over description over content.
+const searchPostsForUser = `SELECT p.id, p.title, p.url, p.published_at, f.name AS feed_name, f.url AS feed_url,
+    -bm25(posts_fts, 0, 10.0, 4.0, 1.0) AS rank,
+    snippet(posts_fts, -1, '**', '**', '...', 30) AS snippet
+FROM posts_fts
+JOIN posts p ON p.id = posts_fts.post_id
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+JOIN feeds f ON f.id = p.feed_id
+WHERE posts_fts MATCH $1
+    AND f_f.user_id = $2
+    AND ($3 IS NULL OR f.url = $3 OR f.name = $3)
+    AND ($4 IS NULL OR p.published_at >= $4)
+ORDER BY rank DESC, p.published_at DESC NULLS LAST
+LIMIT $5`
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg database.SearchPostsForUserParams) ([]database.SearchPostsForUserRow, error) {
+	match := matchExpression(arg.Query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		match,
+		arg.UserID,
+		arg.Feed,
+		arg.Since,
+		arg.PostLimit,
+	)
+	return collect(rows, err, func(row scanner) (database.SearchPostsForUserRow, error) {
+		var i database.SearchPostsForUserRow
+		err := row.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.Rank,
+			&i.Snippet,
+		)
+		return i, err
+	})
+}
+
+// matchExpression translates a query in the web search syntax that
+// websearch_to_tsquery accepts on PostgreSQL into an FTS5 match expression:
+// words and quoted phrases must all match, "or" between two of them matches
+// either and a leading - excludes one. Every term is quoted so that FTS5
+// never reads the user's input as its own syntax. It returns "" when the
+// query has nothing to look for.
+func matchExpression(query string) string {
+	var (
+		all     [][]string
+		exclude []string
+		or      bool
+	)
+	for _, token := range tokenize(query) {
+		if !token.phrase && strings.EqualFold(token.text, "or") {
+			or = len(all) > 0
+			continue
+		}
+		term := ftsString(token.text)
+		if term == "" {
+			continue
+		}
+		switch {
+		case token.exclude:
+			exclude = append(exclude, term)
+		case or:
+			all[len(all)-1] = append(all[len(all)-1], term)
+		default:
+			all = append(all, []string{term})
+		}
+		or = false
+	}
+	if len(all) == 0 {
+		return ""
+	}
+
+	clauses := make([]string, 0, len(all))
+	for _, terms := range all {
+		clauses = append(clauses, "("+strings.Join(terms, " OR ")+")")
+	}
+	expr := strings.Join(clauses, " AND ")
+	for _, term := range exclude {
+		expr = "(" + expr + ") NOT " + term
+	}
+	return expr
+}
+
+type searchToken struct {
+	text    string
+	phrase  bool
+	exclude bool
+}
+
+func tokenize(query string) []searchToken {
+	var tokens []searchToken
+	for query != "" {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		if query == "" {
+			break
+		}
+
+		var token searchToken
+		if strings.HasPrefix(query, "-") {
+			token.exclude = true
+			query = query[1:]
+		}
+		if rest, ok := strings.CutPrefix(query, `"`); ok {
+			token.phrase = true
+			token.text, query, _ = strings.Cut(rest, `"`)
+		} else {
+			end := strings.IndexFunc(query, unicode.IsSpace)
+			if end < 0 {
+				end = len(query)
+			}
+			token.text, query = query[:end], query[end:]
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// ftsString quotes text as an FTS5 string, or returns "" when it has no
+// letters or digits for the tokenizer to index.
+func ftsString(text string) string {
+	if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const createSession = `INSERT INTO sessions (id, created_at, expires_at, user_id, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at, expires_at, user_id, token_hash`
+
+func (q *Queries) CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.UserID,
+		arg.TokenHash,
+	)
+	var i database.Session
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UserID,
+		&i.TokenHash,
+	)
+	return i, err
+}
+
+const deleteSession = `DELETE FROM sessions
+WHERE token_hash = $1`
+
+func (q *Queries) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, deleteSession, tokenHash)
+	return err
+}
+
+const deleteUserSessions = `DELETE FROM sessions
+WHERE user_id = $1`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSessions, userID)
+	return err
+}
+
+const getUserBySession = `SELECT ` + userColumns + ` FROM users
+JOIN sessions ON sessions.user_id = users.id
+WHERE sessions.token_hash = $1 AND sessions.expires_at > $2`
+
+func (q *Queries) GetUserBySession(ctx context.Context, arg database.GetUserBySessionParams) (database.User, error) {
+	return scanUser(q.db.QueryRowContext(ctx, getUserBySession, arg.TokenHash, arg.ExpiresAt))
+}
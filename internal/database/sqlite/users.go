@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+const userColumns = `users.id, users.created_at, users.updated_at, users.name, users.password_hash`
+
+func scanUser(row scanner) (database.User, error) {
+	var i database.User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const createUser = `INSERT INTO users (id, created_at, updated_at, name, password_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + userColumns
+
+func (q *Queries) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	i, err := scanUser(q.db.QueryRowContext(ctx, createUser,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.PasswordHash,
+	))
+	return i, conflict(err)
+}
+
+const deleteUsers = `DELETE FROM users`
+
+func (q *Queries) DeleteUsers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteUsers)
+	return err
+}
+
+const getUser = `SELECT ` + userColumns + ` FROM users
+WHERE name = $1`
+
+func (q *Queries) GetUser(ctx context.Context, name string) (database.User, error) {
+	return scanUser(q.db.QueryRowContext(ctx, getUser, name))
+}
+
+const getUserById = `SELECT ` + userColumns + ` FROM users
+WHERE id = $1`
+
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return scanUser(q.db.QueryRowContext(ctx, getUserById, id))
+}
+
+const getUsers = `SELECT ` + userColumns + ` FROM users`
+
+func (q *Queries) GetUsers(ctx context.Context) ([]database.User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsers)
+	return collect(rows, err, scanUser)
+}
+
+const setUserPassword = `UPDATE users
+SET password_hash = $2, updated_at = $3
+WHERE id = $1`
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.PasswordHash, arg.UpdatedAt)
+	return err
+}
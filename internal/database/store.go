@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrConflict is returned by the Store when a row can't be created because
+// it would duplicate a unique one, such as a user name or a feed URL.
+var ErrConflict = errors.New("already exists")
+
+// Store is everything the commands and the API read and write: the queries
+// sqlc generates plus the hand-written ones. Queries implements it on
+// PostgreSQL and the sqlite package on SQLite.
+type Store interface {
+	Querier
+	ListPostsForUser(ctx context.Context, arg ListPostsForUserParams) ([]ListPostsForUserRow, error)
+}
+
+var _ Store = (*Queries)(nil)
+
+// NewStore returns the PostgreSQL Store: the generated queries, except that
+// the ones creating rows under a unique constraint report a duplicate as
+// ErrConflict.
+func NewStore(db DBTX) Store {
+	return store{New(db)}
+}
+
+type store struct {
+	*Queries
+}
+
+func (s store) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	i, err := s.Queries.CreateAPIKey(ctx, arg)
+	return i, conflict(err)
+}
+
+func (s store) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
+	i, err := s.Queries.CreateFeed(ctx, arg)
+	return i, conflict(err)
+}
+
+func (s store) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
+	i, err := s.Queries.CreateFeedFollow(ctx, arg)
+	return i, conflict(err)
+}
+
+func (s store) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	i, err := s.Queries.CreateUser(ctx, arg)
+	return i, conflict(err)
+}
+
+// uniqueViolation is the SQLSTATE of a unique_violation.
+const uniqueViolation = "23505"
+
+// conflict wraps err with ErrConflict if it's a unique violation.
+func conflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+// Package dateparse parses the many date formats found in syndication
+// feeds, well beyond the RFC 822 and RFC 3339 layouts the specs mandate.
+package dateparse
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDate = errors.New("unrecognized date format")
+
+// layouts are tried in order after the value has been normalized: weekday
+// names are stripped and zone abbreviations replaced by numeric offsets.
+var layouts = []string{
+	// RFC 822 / RFC 1123 and their common mutations.
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05.000 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"2 January 2006",
+	"2-Jan-2006 15:04:05 -0700",
+	"2-Jan-2006",
+
+	// RFC 850, "Monday, 02-Jan-06 15:04:05 MST", still sent by old servers.
+	"2-Jan-06 15:04:05 -0700",
+
+	// ISO 8601 / RFC 3339 and their common mutations.
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05 -0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"20060102T150405Z0700",
+	"20060102",
+	"2006/01/02 15:04:05 -0700",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+
+	// US style dates.
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 2006 3:04:05 PM -0700",
+	"Jan 2 2006 3:04:05 PM",
+	"Jan 2 2006 3:04 PM",
+	"Jan 2 2006",
+	"January 2 2006 15:04:05 -0700",
+	"January 2 2006 15:04:05",
+	"January 2 2006 3:04 PM",
+	"January 2 2006",
+	"Jan 2 15:04:05 2006",
+	"Jan 2 15:04:05 -0700 2006",
+	"01/02/2006 15:04:05",
+	"01/02/2006 3:04:05 PM",
+	"01/02/2006 3:04 PM",
+	"01/02/2006",
+
+	// European numeric dates.
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// zones maps the abbreviations commonly found in feeds to their offsets.
+// Go only resolves abbreviations of the local zone, so anything else would
+// silently be parsed as UTC.
+var zones = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"BST":  "+0100",
+	"IST":  "+0530",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"MET":  "+0100",
+	"MEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"PKT":  "+0500",
+	"ICT":  "+0700",
+	"WIB":  "+0700",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"AST":  "-0400",
+	"ADT":  "-0300",
+	"NST":  "-0330",
+	"NDT":  "-0230",
+	"BRT":  "-0300",
+	"ART":  "-0300",
+	"HKT":  "+0800",
+	"SGT":  "+0800",
+	"AWST": "+0800",
+	"PHT":  "+0800",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"ACST": "+0930",
+	"ACDT": "+1030",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+var (
+	weekdayPrefix = regexp.MustCompile(`(?i)^(mon|tue|wed|thu|fri|sat|sun)[a-z]*\.?,?\s+`)
+	ordinalSuffix = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
+	gmtOffset     = regexp.MustCompile(`(?i)^(?:GMT|UTC)([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	numericOffset = regexp.MustCompile(`^[+-]\d{4}$`)
+	spaces        = regexp.MustCompile(`\s+`)
+	unixTimestamp = regexp.MustCompile(`^\d{9,10}$`)
+)
+
+// Parse interprets value as a date, trying a large set of layouts and
+// resolving zone abbreviations. Dates without a zone are assumed to be UTC.
+// The result is always in UTC.
+func Parse(value string) (time.Time, error) {
+	value = normalize(value)
+	if value == "" {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	if unixTimestamp.MatchString(value) {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			return time.Unix(seconds, 0).UTC(), nil
+		}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, ErrInvalidDate
+}
+
+// normalize rewrites value into a shape the layouts can match: single
+// spaces, no weekday, no ordinal suffixes and numeric zone offsets.
+func normalize(value string) string {
+	value = strings.TrimSpace(spaces.ReplaceAllString(value, " "))
+	value = weekdayPrefix.ReplaceAllString(value, "")
+	value = ordinalSuffix.ReplaceAllString(value, "$1")
+	// "Jan 2, 2006" and "2 Jan, 2006" are matched by the comma-less layouts.
+	value = strings.ReplaceAll(value, ",", "")
+
+	tokens := strings.Split(value, " ")
+	normalized := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		token = normalizeToken(token)
+		// Zones are often spelled twice, as in "+0000 (UTC)"; keep the
+		// numeric offset only.
+		if numericOffset.MatchString(token) && len(normalized) > 0 && numericOffset.MatchString(normalized[len(normalized)-1]) {
+			continue
+		}
+		normalized = append(normalized, token)
+	}
+	return strings.Join(normalized, " ")
+}
+
+func normalizeToken(token string) string {
+	bare := strings.Trim(token, "()")
+	if offset, ok := zones[strings.ToUpper(bare)]; ok {
+		return offset
+	}
+	if match := gmtOffset.FindStringSubmatch(bare); match != nil {
+		hours := match[2]
+		if len(hours) == 1 {
+			hours = "0" + hours
+		}
+		minutes := match[3]
+		if minutes == "" {
+			minutes = "00"
+		}
+		return match[1] + hours + minutes
+	}
+	if strings.EqualFold(bare, "Sept") {
+		return "Sep"
+	}
+	return token
+}
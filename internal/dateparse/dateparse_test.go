@@ -0,0 +1,76 @@
+package dateparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		// RFC 822 and RFC 1123, as mandated by RSS 2.0.
+		{"Mon, 02 Jan 2006 15:04:05 GMT", "2006-01-02T15:04:05Z"},
+		{"Sun, 19 May 2002 15:21:36 GMT", "2002-05-19T15:21:36Z"},
+		{"Wed, 02 Oct 2002 08:00:00 EST", "2002-10-02T13:00:00Z"},
+		{"Wed, 02 Oct 2002 13:00:00 +0000", "2002-10-02T13:00:00Z"},
+		{"Sat, 07 Sep 2002 00:00:01 PDT", "2002-09-07T07:00:01Z"},
+		{"Thu, 5 Oct 2017 14:30:00 +0200", "2017-10-05T12:30:00Z"},
+		{"Sat, 30 Nov 2013 12:00:00 CET", "2013-11-30T11:00:00Z"},
+		{"Thu, 01 Jan 1970 00:00:00 +0000", "1970-01-01T00:00:00Z"},
+		{"2 Jan 06 15:04 -0700", "2006-01-02T22:04:00Z"},
+
+		// Mutations of RFC 822 found in the wild.
+		{"Fri, 21 Jul 2023 09:04:00 +0000 (UTC)", "2023-07-21T09:04:00Z"},
+		{"Tue, 10 Jun 2003 04:00:00 GMT+2", "2003-06-10T02:00:00Z"},
+		{"Tue, 10 Sept 2019 10:00:00 GMT", "2019-09-10T10:00:00Z"},
+		{"Tuesday, 10 June 2003 04:00:00 +0000", "2003-06-10T04:00:00Z"},
+		{"  Mon,  02 Jan 2006   15:04:05 GMT ", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05", "2006-01-02T15:04:05Z"},
+
+		// RFC 850, the obsolete HTTP date format.
+		{"Sunday, 06-Nov-94 08:49:37 GMT", "1994-11-06T08:49:37Z"},
+		{"Monday, 02-Jan-06 15:04:05 MST", "2006-01-02T22:04:05Z"},
+
+		// RFC 3339, as mandated by Atom and JSON Feed.
+		{"2003-12-13T18:30:02Z", "2003-12-13T18:30:02Z"},
+		{"2003-12-13T18:30:02.25Z", "2003-12-13T18:30:02.25Z"},
+		{"2003-12-13T18:30:02+01:00", "2003-12-13T17:30:02Z"},
+		{"2024-03-10T12:00:00-0500", "2024-03-10T17:00:00Z"},
+		{"2024-03-10T12:00:00", "2024-03-10T12:00:00Z"},
+		{"2024-03-10 12:00:00", "2024-03-10T12:00:00Z"},
+		{"2024-03-10", "2024-03-10T00:00:00Z"},
+
+		// Human-written and other formats.
+		{"March 5th, 2024", "2024-03-05T00:00:00Z"},
+		{"Jan 2, 2006 3:04 PM", "2006-01-02T15:04:00Z"},
+		{"Mon Jan  2 15:04:05 2006", "2006-01-02T15:04:05Z"},
+		{"02.01.2006 15:04", "2006-01-02T15:04:00Z"},
+		{"1700000000", "2023-11-14T22:13:20Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			want, err := time.Parse(time.RFC3339Nano, tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.value, err)
+			}
+			if !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("Parse(%q) = %v, want %v", tt.value, got, want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "   ", "yesterday", "not a date", "32 Jan 2006", "2024-13-01"} {
+		if got, err := Parse(value); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("Parse(%q) = %v, %v, want ErrInvalidDate", value, got, err)
+		}
+	}
+}
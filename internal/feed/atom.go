@@ -0,0 +1,162 @@
+package feed
+
+import (
+	"strings"
+)
+
+type atomDocument struct {
+	Title    atomText     `xml:"title"`
+	Subtitle atomText     `xml:"subtitle"`
+	Links    []atomLink   `xml:"link"`
+	Authors  []atomPerson `xml:"author"`
+	Entries  []atomEntry  `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+// atomText is an Atom text construct. XHTML content is wrapped in a <div>
+// whose markup is kept as-is.
+type atomText struct {
+	Type     string `xml:"type,attr"`
+	Value    string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Title  string `xml:"title,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+	URI   string `xml:"uri"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// Text returns the construct as plain text. Only html constructs carry
+// escaped HTML entities: in text ones, the default, an entity such as &amp;
+// is literal once the XML is decoded.
+func (t atomText) Text() string {
+	switch t.Type {
+	case "xhtml":
+		return strings.TrimSpace(t.InnerXML)
+	case "html":
+		return textValue(t.Value)
+	}
+	return strings.TrimSpace(t.Value)
+}
+
+// HTML returns the construct as markup, suitable for content bodies.
+func (t atomText) HTML() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.InnerXML)
+	}
+	return strings.TrimSpace(t.Value)
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var doc atomDocument
+	if err := decode(data, &doc); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:      FormatAtom,
+		Title:       doc.Title.Text(),
+		Link:        alternateLink(doc.Links),
+		Description: doc.Subtitle.Text(),
+		Items:       make([]Item, 0, len(doc.Entries)),
+	}
+	for _, entry := range doc.Entries {
+		authors := entry.Authors
+		if len(authors) == 0 {
+			// Entries inherit the feed authors when they don't declare their own.
+			authors = doc.Authors
+		}
+		feed.Items = append(feed.Items, Item{
+			GUID:       strings.TrimSpace(entry.ID),
+			Title:      entry.Title.Text(),
+			Link:       alternateLink(entry.Links),
+			Summary:    entry.Summary.Text(),
+			Content:    entry.Content.HTML(),
+			Authors:    atomPeople(authors),
+			Categories: atomCategories(entry.Categories),
+			Enclosures: atomEnclosures(entry.Links),
+			Published:  parseDate(entry.Published, entry.Updated),
+			Updated:    parseDate(entry.Updated),
+		})
+	}
+	return feed, nil
+}
+
+// alternateLink returns the link pointing to the HTML version of the
+// resource, which is the one with rel="alternate" or no rel at all.
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func atomEnclosures(links []atomLink) []Enclosure {
+	var enclosures []Enclosure
+	for _, link := range links {
+		if link.Rel != "enclosure" || link.Href == "" {
+			continue
+		}
+		enclosures = append(enclosures, Enclosure{
+			URL:    strings.TrimSpace(link.Href),
+			Type:   link.Type,
+			Title:  link.Title,
+			Length: link.Length,
+		})
+	}
+	return enclosures
+}
+
+func atomPeople(authors []atomPerson) []Person {
+	people := make([]Person, 0, len(authors))
+	for _, author := range authors {
+		people = append(people, Person{
+			Name:  textValue(author.Name),
+			Email: strings.TrimSpace(author.Email),
+			URI:   strings.TrimSpace(author.URI),
+		})
+	}
+	return people
+}
+
+func atomCategories(categories []atomCategory) []string {
+	var values []string
+	for _, category := range categories {
+		if category.Label != "" {
+			values = append(values, textValue(category.Label))
+		} else if category.Term != "" {
+			values = append(values, textValue(category.Term))
+		}
+	}
+	return values
+}
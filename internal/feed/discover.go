@@ -0,0 +1,200 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrNoFeedFound = errors.New("no feed found")
+
+// Candidate is a feed found while discovering feeds from a URL.
+type Candidate struct {
+	URL   string
+	Title string
+	Type  string
+	// Feed is the parsed document when discovery had to download it anyway,
+	// and nil for the feeds a page links to.
+	Feed *Feed
+}
+
+// feedMediaTypes are the <link rel="alternate"> types announcing a feed.
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// jsonFeedHint tells the JSON Feeds announced as plain application/json
+// apart from the other JSON documents pages link to, such as the WordPress
+// REST API or oEmbed, by their URL or title.
+var jsonFeedHint = regexp.MustCompile(`(?i)\bfeed\b|jsonfeed`)
+
+// commonPaths are probed when a page doesn't announce its feeds.
+var commonPaths = []string{
+	"/feed",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed.xml",
+	"/index.xml",
+	"/rss",
+	"/feed.json",
+}
+
+// Discover returns the feeds available at pageURL. When pageURL is a feed
+// itself it is the only candidate; when it is an HTML page, the feeds it
+// links to are returned, falling back to probing common feed locations.
+func Discover(ctx context.Context, pageURL string) ([]Candidate, error) {
+	data, contentType, finalURL, err := get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if feed, err := Parse(data, contentType); err == nil {
+		return []Candidate{{URL: finalURL, Title: feed.Title, Type: string(feed.Format), Feed: feed}}, nil
+	}
+
+	base, err := url.Parse(finalURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	candidates := linkedFeeds(data, base)
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, path := range commonPaths {
+		probeURL := base.ResolveReference(&url.URL{Path: path}).String()
+		data, contentType, finalURL, err := get(ctx, probeURL)
+		if err != nil {
+			continue
+		}
+		if feed, err := Parse(data, contentType); err == nil {
+			candidates = append(candidates, Candidate{URL: finalURL, Title: feed.Title, Type: string(feed.Format), Feed: feed})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoFeedFound
+	}
+	return dedupe(candidates), nil
+}
+
+func get(ctx context.Context, rawURL string) ([]byte, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("couldn't fetch %s: %w", rawURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", "", &StatusError{StatusCode: res.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("couldn't read response body: %w", err)
+	}
+	return data, res.Header.Get("Content-Type"), res.Request.URL.String(), nil
+}
+
+// linkedFeeds extracts the feeds announced by <link rel="alternate"> tags,
+// resolving them against the page's <base> or URL.
+func linkedFeeds(data []byte, base *url.URL) []Candidate {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "base":
+				if href := attr(n, "href"); href != "" {
+					if resolved, err := base.Parse(href); err == nil {
+						base = resolved
+					}
+				}
+			case "link":
+				if candidate, ok := linkCandidate(n, base); ok {
+					candidates = append(candidates, candidate)
+				}
+			case "body":
+				// Feed links only appear in the document head.
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return dedupe(candidates)
+}
+
+func linkCandidate(n *html.Node, base *url.URL) (Candidate, bool) {
+	rels := strings.Fields(strings.ToLower(attr(n, "rel")))
+	isAlternate := false
+	for _, rel := range rels {
+		if rel == "alternate" {
+			isAlternate = true
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(attr(n, "type"))
+	href := attr(n, "href")
+	title := strings.TrimSpace(attr(n, "title"))
+	isFeed := feedMediaTypes[mediaType] ||
+		mediaType == "application/json" && (jsonFeedHint.MatchString(href) || jsonFeedHint.MatchString(title))
+	if !isAlternate || !isFeed || href == "" {
+		return Candidate{}, false
+	}
+
+	resolved, err := base.Parse(href)
+	if err != nil {
+		return Candidate{}, false
+	}
+	return Candidate{
+		URL:   resolved.String(),
+		Title: title,
+		Type:  mediaType,
+	}, true
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func dedupe(candidates []Candidate) []Candidate {
+	seen := make(map[string]bool)
+	var unique []Candidate
+	for _, candidate := range candidates {
+		if seen[candidate.URL] {
+			continue
+		}
+		seen[candidate.URL] = true
+		unique = append(unique, candidate)
+	}
+	return unique
+}
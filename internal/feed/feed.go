@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"time"
+)
+
+// Format identifies the syndication format a document was parsed from.
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatRDF  Format = "rdf"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// Feed is the normalized representation of a syndication document,
+// independent of the format it was published in.
+type Feed struct {
+	Format      Format
+	Title       string
+	Link        string
+	Description string
+	// TTL is how long the publisher asks readers to wait between polls,
+	// taken from <ttl> or the syndication module. Zero when not declared.
+	TTL   time.Duration
+	Items []Item
+}
+
+// Item is a single entry of a Feed.
+type Item struct {
+	GUID       string
+	Title      string
+	Link       string
+	Summary    string
+	Content    string
+	Authors    []Person
+	Categories []string
+	Enclosures []Enclosure
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Person is the author or contributor of an item.
+type Person struct {
+	Name  string
+	Email string
+	URI   string
+}
+
+// Enclosure is a file attached to an item, such as a podcast episode.
+type Enclosure struct {
+	URL    string
+	Type   string
+	Title  string
+	Length int64
+	// Duration is the length of the media in seconds, when known.
+	Duration float64
+}
+
+// ID returns a stable identifier for the item, falling back to its link
+// when the feed doesn't provide a GUID.
+func (i Item) ID() string {
+	if i.GUID != "" {
+		return i.GUID
+	}
+	return i.Link
+}
+
+// Description returns the summary of the item, or its content when no
+// summary was provided.
+func (i Item) Description() string {
+	if i.Summary != "" {
+		return i.Summary
+	}
+	return i.Content
+}
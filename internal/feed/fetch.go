@@ -0,0 +1,170 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/metrics"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	userAgent   = "gator"
+	maxBodySize = 10 << 20
+)
+
+var httpClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// StatusError is returned when the server answers with a status code
+// other than 200 or 304.
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by a Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Request describes a feed to download. ETag and LastModified are the
+// validators returned by a previous fetch and turn the request into a
+// conditional GET when set.
+type Request struct {
+	URL          string
+	ETag         string
+	LastModified string
+}
+
+// Response is the outcome of a successful fetch. When NotModified is true
+// the server answered 304 and Feed is nil.
+type Response struct {
+	// URL is the address the document was served from after following
+	// redirects, which is the canonical URL of the feed.
+	URL          string
+	Feed         *Feed
+	NotModified  bool
+	StatusCode   int
+	ETag         string
+	LastModified string
+	// Expires is when the response becomes stale according to the
+	// Cache-Control or Expires headers. Zero when the server didn't say.
+	Expires time.Time
+}
+
+// Fetch downloads the document described by r and parses it.
+func Fetch(ctx context.Context, r Request) (*Response, error) {
+	start := time.Now()
+	outcome := metrics.OutcomeNetworkError
+	defer func() {
+		if outcome == metrics.OutcomeNetworkError && ctx.Err() != nil {
+			outcome = metrics.OutcomeCanceled
+		}
+		metrics.FeedFetches.WithLabelValues(outcome).Inc()
+		metrics.FetchDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/rdf+xml, application/feed+json, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if r.ETag != "" {
+		req.Header.Set("If-None-Match", r.ETag)
+	}
+	if r.LastModified != "" {
+		req.Header.Set("If-Modified-Since", r.LastModified)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch feed: %w", err)
+	}
+	defer res.Body.Close()
+
+	response := &Response{
+		URL:          res.Request.URL.String(),
+		StatusCode:   res.StatusCode,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Expires:      expiresAt(res.Header, time.Now()),
+	}
+
+	if res.StatusCode == http.StatusNotModified {
+		outcome = metrics.OutcomeNotModified
+		response.NotModified = true
+		// Servers may omit validators on a 304; keep the ones we sent.
+		if response.ETag == "" {
+			response.ETag = r.ETag
+		}
+		if response.LastModified == "" {
+			response.LastModified = r.LastModified
+		}
+		return response, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		outcome = metrics.OutcomeHTTPError
+		return nil, &StatusError{
+			StatusCode: res.StatusCode,
+			RetryAfter: retryAfter(res.Header, time.Now()),
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	metrics.DownloadedBytes.Add(float64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read response body: %w", err)
+	}
+
+	response.Feed, err = Parse(data, res.Header.Get("Content-Type"))
+	if err != nil {
+		outcome = metrics.OutcomeParseError
+		return nil, err
+	}
+	outcome = metrics.OutcomeSuccess
+	return response, nil
+}
+
+// expiresAt derives the expiry time of a response, preferring the
+// Cache-Control max-age directive over the Expires header.
+func expiresAt(header http.Header, now time.Time) time.Time {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return time.Time{}
+		case "max-age", "s-maxage":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil && seconds > 0 {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil && expires.After(now) {
+		return expires
+	}
+	return time.Time{}
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
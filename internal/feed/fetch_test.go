@@ -0,0 +1,89 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFetchConditional(t *testing.T) {
+	const (
+		etag         = `"v1"`
+		lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	)
+	data, err := os.ReadFile(filepath.Join("testdata", "rss.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("User-Agent") != userAgent {
+			t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), userAgent)
+		}
+		// Answer 304 without repeating the validators, as many servers do.
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set("Cache-Control", "public, max-age=600")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	start := time.Now()
+	res, err := Fetch(ctx, Request{URL: server.URL})
+	if err != nil {
+		t.Fatalf("first Fetch returned error: %v", err)
+	}
+	if res.NotModified || res.Feed == nil || len(res.Feed.Items) != 2 {
+		t.Fatalf("first Fetch = %+v, want the parsed feed", res)
+	}
+	if res.StatusCode != http.StatusOK || res.ETag != etag || res.LastModified != lastModified {
+		t.Errorf("first Fetch = %d %q %q, want 200 %q %q", res.StatusCode, res.ETag, res.LastModified, etag, lastModified)
+	}
+	if res.Expires.Before(start.Add(10*time.Minute)) || res.Expires.After(time.Now().Add(10*time.Minute)) {
+		t.Errorf("first Fetch expires at %v, want 10 minutes from now", res.Expires)
+	}
+
+	res, err = Fetch(ctx, Request{URL: server.URL, ETag: res.ETag, LastModified: res.LastModified})
+	if err != nil {
+		t.Fatalf("conditional Fetch returned error: %v", err)
+	}
+	if !res.NotModified || res.Feed != nil || res.StatusCode != http.StatusNotModified {
+		t.Fatalf("conditional Fetch = %+v, want not modified", res)
+	}
+	if res.ETag != etag || res.LastModified != lastModified {
+		t.Errorf("conditional Fetch validators = %q %q, want the ones sent: %q %q", res.ETag, res.LastModified, etag, lastModified)
+	}
+	if requests != 2 {
+		t.Errorf("server received %d requests, want 2", requests)
+	}
+}
+
+func TestFetchStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := Fetch(context.Background(), Request{URL: server.URL})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Fetch returned %v, want a StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.RetryAfter != 2*time.Minute {
+		t.Errorf("Fetch returned %+v, want 503 retrying after 2m", statusErr)
+	}
+}
@@ -0,0 +1,152 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type jsonFeedDocument struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description"`
+	Author      *jsonFeedAuthor  `json:"author"`
+	Authors     []jsonFeedAuthor `json:"authors"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            jsonFeedID           `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Author        *jsonFeedAuthor      `json:"author"`
+	Authors       []jsonFeedAuthor     `json:"authors"`
+	Tags          []string             `json:"tags"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type jsonFeedAttachment struct {
+	URL               string  `json:"url"`
+	MIMEType          string  `json:"mime_type"`
+	Title             string  `json:"title"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+// jsonFeedID accepts both strings and numbers, since the spec requires a
+// string but plenty of publishers emit numeric ids.
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = jsonFeedID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid item id %s", data)
+	}
+	*id = jsonFeedID(n.String())
+	return nil
+}
+
+func parseJSONFeed(data []byte) (*Feed, error) {
+	var doc jsonFeedDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("couldn't decode document: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, ErrUnknownFormat
+	}
+
+	feedAuthors := jsonFeedAuthors(doc.Author, doc.Authors)
+	feed := &Feed{
+		Format:      FormatJSON,
+		Title:       strings.TrimSpace(doc.Title),
+		Link:        strings.TrimSpace(doc.HomePageURL),
+		Description: strings.TrimSpace(doc.Description),
+		Items:       make([]Item, 0, len(doc.Items)),
+	}
+	for _, item := range doc.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+		content := item.ContentHTML
+		if content == "" {
+			content = item.ContentText
+		}
+		authors := jsonFeedAuthors(item.Author, item.Authors)
+		if len(authors) == 0 {
+			authors = feedAuthors
+		}
+
+		feed.Items = append(feed.Items, Item{
+			GUID:       strings.TrimSpace(string(item.ID)),
+			Title:      strings.TrimSpace(item.Title),
+			Link:       strings.TrimSpace(link),
+			Summary:    strings.TrimSpace(item.Summary),
+			Content:    strings.TrimSpace(content),
+			Authors:    authors,
+			Categories: trimAll(item.Tags),
+			Enclosures: jsonFeedEnclosures(item.Attachments),
+			Published:  parseDate(item.DatePublished, item.DateModified),
+			Updated:    parseDate(item.DateModified),
+		})
+	}
+	return feed, nil
+}
+
+// jsonFeedAuthors merges the JSON Feed 1.0 "author" object with the
+// JSON Feed 1.1 "authors" array.
+func jsonFeedAuthors(author *jsonFeedAuthor, authors []jsonFeedAuthor) []Person {
+	if author != nil {
+		authors = append([]jsonFeedAuthor{*author}, authors...)
+	}
+	var people []Person
+	for _, a := range authors {
+		if a.Name == "" && a.URL == "" {
+			continue
+		}
+		people = append(people, Person{
+			Name: strings.TrimSpace(a.Name),
+			URI:  strings.TrimSpace(a.URL),
+		})
+	}
+	return people
+}
+
+func jsonFeedEnclosures(attachments []jsonFeedAttachment) []Enclosure {
+	var enclosures []Enclosure
+	for _, attachment := range attachments {
+		if attachment.URL == "" {
+			continue
+		}
+		enclosures = append(enclosures, Enclosure{
+			URL:      strings.TrimSpace(attachment.URL),
+			Type:     attachment.MIMEType,
+			Title:    attachment.Title,
+			Length:   attachment.SizeInBytes,
+			Duration: attachment.DurationInSeconds,
+		})
+	}
+	return enclosures
+}
@@ -0,0 +1,111 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/dateparse"
+	"golang.org/x/net/html/charset"
+	"html"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Parse detects the format of data and parses it into a normalized Feed.
+// contentType is the media type the document was served with and may be
+// empty, in which case the format is sniffed from the body alone.
+func Parse(data []byte, contentType string) (*Feed, error) {
+	format, err := detectFormat(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return parseJSONFeed(data)
+	case FormatRSS:
+		return parseRSS(data)
+	case FormatRDF:
+		return parseRDF(data)
+	case FormatAtom:
+		return parseAtom(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func detectFormat(data []byte, contentType string) (Format, error) {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/feed+json", "application/json":
+			return FormatJSON, nil
+		}
+	}
+	// JSON Feeds are regularly served as text/plain or text/html, so sniff
+	// the body before assuming XML.
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return FormatJSON, nil
+	}
+
+	decoder := newDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", ErrUnknownFormat
+			}
+			return "", fmt.Errorf("couldn't read document: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(start.Name.Local) {
+		case "rss":
+			return FormatRSS, nil
+		case "rdf":
+			return FormatRDF, nil
+		case "feed":
+			return FormatAtom, nil
+		}
+		return "", ErrUnknownFormat
+	}
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	// Many feeds in the wild contain HTML entities and unescaped ampersands.
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+func decode(data []byte, v any) error {
+	if err := newDecoder(data).Decode(v); err != nil {
+		return fmt.Errorf("couldn't decode document: %w", err)
+	}
+	return nil
+}
+
+// textValue trims and unescapes text that feeds commonly double-escape.
+func textValue(s string) string {
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// parseDate returns the first of values that is a valid date, or the zero
+// time when none is.
+func parseDate(values ...string) time.Time {
+	for _, value := range values {
+		if t, err := dateparse.Parse(value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
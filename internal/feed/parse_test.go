@@ -0,0 +1,203 @@
+package feed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string
+		want        *Feed
+	}{
+		{
+			file:        "rss.xml",
+			contentType: "application/rss+xml; charset=utf-8",
+			want: &Feed{
+				Format:      FormatRSS,
+				Title:       "Example & Co",
+				Link:        "https://example.com/",
+				Description: "News from Example",
+				TTL:         90 * time.Minute,
+				Items: []Item{
+					{
+						GUID:    "urn:example:1",
+						Title:   "First post",
+						Link:    "https://example.com/first",
+						Summary: "A <b>short</b> summary",
+						Content: "<p>The whole post.</p>",
+						Authors: []Person{
+							{Name: "Jane Doe", Email: "jane@example.com"},
+							{Name: "John Roe"},
+						},
+						Categories: []string{"Go", "Feeds"},
+						Enclosures: []Enclosure{
+							{URL: "https://example.com/first.mp3", Type: "audio/mpeg", Length: 1024},
+						},
+						Published: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+					},
+					{
+						Title:     "Second post",
+						Link:      "https://example.com/second",
+						Summary:   "Only a link & no guid",
+						Authors:   []Person{{Email: "ops@example.com"}},
+						Published: time.Date(2006, 1, 3, 10, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			file: "rdf.xml",
+			want: &Feed{
+				Format:      FormatRDF,
+				Title:       "Café Example",
+				Link:        "https://example.org/",
+				Description: "An RSS 1.0 channel",
+				TTL:         6 * time.Hour,
+				Items: []Item{
+					{
+						GUID:       "https://example.org/items/1",
+						Title:      "Item one",
+						Link:       "https://example.org/items/1",
+						Summary:    "First item",
+						Authors:    []Person{{Name: "Ann"}},
+						Categories: []string{"News"},
+						Published:  time.Date(2024, 3, 10, 11, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			file:        "atom.xml",
+			contentType: "application/atom+xml",
+			want: &Feed{
+				Format:      FormatAtom,
+				Title:       "Atom & Example",
+				Link:        "https://example.net/",
+				Description: "Written in Atom &amp; plain text",
+				Items: []Item{
+					{
+						GUID:       "tag:example.net,2024:1",
+						Title:      "Entry with content",
+						Link:       "https://example.net/entries/1",
+						Summary:    "Plain summary",
+						Content:    `<div xmlns="http://www.w3.org/1999/xhtml"><p>Rich</p></div>`,
+						Authors:    []Person{{Name: "Entry Author", URI: "https://example.net/about"}},
+						Categories: []string{"Go", "web"},
+						Enclosures: []Enclosure{
+							{URL: "https://example.net/1.ogg", Type: "audio/ogg", Title: "Episode", Length: 2048},
+						},
+						Published: time.Date(2024, 4, 30, 6, 0, 0, 0, time.UTC),
+						Updated:   time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+					},
+					{
+						GUID:      "tag:example.net,2024:2",
+						Title:     "AT&amp;T entry without published date",
+						Link:      "https://example.net/entries/2",
+						Content:   "<p>Escaped</p>",
+						Authors:   []Person{{Name: "Feed Author", Email: "author@example.net"}},
+						Published: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC),
+						Updated:   time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			// JSON Feeds are often served as text/plain.
+			file:        "feed.json",
+			contentType: "text/plain",
+			want: &Feed{
+				Format:      FormatJSON,
+				Title:       "JSON Example",
+				Link:        "https://example.io/",
+				Description: "A JSON Feed",
+				Items: []Item{
+					{
+						GUID:       "https://example.io/1",
+						Title:      "First",
+						Link:       "https://example.io/1",
+						Summary:    "Hello in short",
+						Content:    "<p>Hello</p>",
+						Authors:    []Person{{Name: "Feed Author", URI: "https://example.io/me"}},
+						Categories: []string{"go"},
+						Enclosures: []Enclosure{
+							{URL: "https://example.io/1.mp3", Type: "audio/mpeg", Length: 4096, Duration: 61.5},
+						},
+						Published: time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC),
+						Updated:   time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC),
+					},
+					{
+						GUID:    "2",
+						Link:    "https://elsewhere.example/2",
+						Content: "Plain text",
+						Authors: []Person{{Name: "Guest"}},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(data, tt.contentType)
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			if len(got.Items) != len(tt.want.Items) {
+				t.Fatalf("Parse returned %d items, want %d", len(got.Items), len(tt.want.Items))
+			}
+			for i := range got.Items {
+				if !reflect.DeepEqual(got.Items[i], tt.want.Items[i]) {
+					t.Errorf("item %d = %+v\nwant %+v", i, got.Items[i], tt.want.Items[i])
+				}
+			}
+			got.Items, tt.want.Items = nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+	}{
+		{"html page", "<!DOCTYPE html><html><body>Hello</body></html>", "text/html"},
+		{"empty document", "", ""},
+		{"json without version", `{"title": "Not a feed"}`, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Parse([]byte(tt.data), tt.contentType); !errors.Is(err, ErrUnknownFormat) {
+				t.Errorf("Parse = %v, %v, want ErrUnknownFormat", got, err)
+			}
+		})
+	}
+}
+
+func TestItemID(t *testing.T) {
+	tests := []struct {
+		item Item
+		want string
+	}{
+		{Item{GUID: "urn:1", Link: "https://example.com/1"}, "urn:1"},
+		{Item{Link: "https://example.com/1"}, "https://example.com/1"},
+		{Item{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.item.ID(); got != tt.want {
+			t.Errorf("%+v.ID() = %q, want %q", tt.item, got, tt.want)
+		}
+	}
+}
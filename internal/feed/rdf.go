@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"strings"
+)
+
+// rdfDocument models RSS 1.0, where items are siblings of the channel
+// rather than children of it.
+type rdfDocument struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		syndication
+	} `xml:"channel"`
+	Items []rdfItem `xml:"item"`
+}
+
+type rdfItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+func parseRDF(data []byte) (*Feed, error) {
+	var doc rdfDocument
+	if err := decode(data, &doc); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:      FormatRDF,
+		Title:       textValue(doc.Channel.Title),
+		Link:        strings.TrimSpace(doc.Channel.Link),
+		Description: textValue(doc.Channel.Description),
+		TTL:         doc.Channel.interval(),
+		Items:       make([]Item, 0, len(doc.Items)),
+	}
+	for _, item := range doc.Items {
+		feed.Items = append(feed.Items, Item{
+			GUID:       strings.TrimSpace(item.About),
+			Title:      textValue(item.Title),
+			Link:       strings.TrimSpace(item.Link),
+			Summary:    textValue(item.Description),
+			Content:    strings.TrimSpace(item.Content),
+			Authors:    rssAuthors("", item.Creators),
+			Categories: trimAll(item.Subjects),
+			Published:  parseDate(item.Date),
+		})
+	}
+	return feed, nil
+}
@@ -0,0 +1,174 @@
+package feed
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rssLink captures <link> elements; RSS channels frequently embed an empty
+// <atom:link rel="self"/> next to the plain link which must be ignored.
+type rssLink struct {
+	Value string `xml:",chardata"`
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title       string    `xml:"title"`
+		Links       []rssLink `xml:"link"`
+		Description string    `xml:"description"`
+		TTL         string    `xml:"ttl"`
+		syndication
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// syndication holds the RSS syndication module hints, which can appear in
+// both RSS 2.0 and RSS 1.0 channels.
+type syndication struct {
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+func (s syndication) interval() time.Duration {
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(s.UpdatePeriod)) {
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 30 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+
+	frequency, err := strconv.Atoi(strings.TrimSpace(s.UpdateFrequency))
+	if err != nil || frequency < 1 {
+		frequency = 1
+	}
+	return period / time.Duration(frequency)
+}
+
+// ttlMinutes parses the RSS <ttl> element, expressed in minutes.
+func ttlMinutes(value string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || minutes < 1 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type rssItem struct {
+	Title       string         `xml:"title"`
+	Links       []rssLink      `xml:"link"`
+	GUID        string         `xml:"guid"`
+	Description string         `xml:"description"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string         `xml:"author"`
+	Creators    []string       `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []rssEnclosure `xml:"enclosure"`
+	Subjects    []string       `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	PubDate     string         `xml:"pubDate"`
+	Date        string         `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var doc rssDocument
+	if err := decode(data, &doc); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:      FormatRSS,
+		Title:       textValue(doc.Channel.Title),
+		Link:        firstLink(doc.Channel.Links),
+		Description: textValue(doc.Channel.Description),
+		TTL:         max(ttlMinutes(doc.Channel.TTL), doc.Channel.interval()),
+		Items:       make([]Item, 0, len(doc.Channel.Items)),
+	}
+	for _, item := range doc.Channel.Items {
+		feed.Items = append(feed.Items, Item{
+			GUID:       strings.TrimSpace(item.GUID),
+			Title:      textValue(item.Title),
+			Link:       firstLink(item.Links),
+			Summary:    textValue(item.Description),
+			Content:    strings.TrimSpace(item.Content),
+			Authors:    rssAuthors(item.Author, item.Creators),
+			Categories: trimAll(append(item.Categories, item.Subjects...)),
+			Enclosures: rssEnclosures(item.Enclosures),
+			Published:  parseDate(item.PubDate, item.Date),
+		})
+	}
+	return feed, nil
+}
+
+func firstLink(links []rssLink) string {
+	for _, link := range links {
+		if value := strings.TrimSpace(link.Value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// rssAuthors handles both the RSS <author> element, which is usually an
+// email address optionally followed by a name in parentheses, and
+// <dc:creator>.
+func rssAuthors(author string, creators []string) []Person {
+	var people []Person
+	if author = strings.TrimSpace(author); author != "" {
+		person := Person{Name: author}
+		if email, name, ok := strings.Cut(author, " ("); ok {
+			person = Person{
+				Name:  strings.TrimSuffix(name, ")"),
+				Email: email,
+			}
+		} else if strings.Contains(author, "@") {
+			person = Person{Email: author}
+		}
+		people = append(people, person)
+	}
+	for _, creator := range creators {
+		if creator = textValue(creator); creator != "" {
+			people = append(people, Person{Name: creator})
+		}
+	}
+	return people
+}
+
+func rssEnclosures(enclosures []rssEnclosure) []Enclosure {
+	var values []Enclosure
+	for _, enclosure := range enclosures {
+		if enclosure.URL == "" {
+			continue
+		}
+		values = append(values, Enclosure{
+			URL:    strings.TrimSpace(enclosure.URL),
+			Type:   enclosure.Type,
+			Length: enclosure.Length,
+		})
+	}
+	return values
+}
+
+func trimAll(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = textValue(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,68 @@
+// Package metrics defines the Prometheus metrics of the aggregator, which
+// agg serves when it is given a --metrics-addr.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Outcomes of a feed fetch, the values of the outcome label.
+const (
+	OutcomeSuccess      = "success"
+	OutcomeNotModified  = "not_modified"
+	OutcomeHTTPError    = "http_error"
+	OutcomeNetworkError = "network_error"
+	OutcomeParseError   = "parse_error"
+	OutcomeCanceled     = "canceled"
+)
+
+var (
+	FeedFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gator_feed_fetches_total",
+		Help: "Feed fetches, by outcome.",
+	}, []string{"outcome"})
+
+	FetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gator_feed_fetch_duration_seconds",
+		Help:    "Time taken to download and parse a feed, by outcome.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"outcome"})
+
+	DownloadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gator_feed_downloaded_bytes_total",
+		Help: "Size of the feed documents downloaded.",
+	})
+
+	SavedPosts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gator_posts_saved_total",
+		Help: "Posts found in collected feeds, by result: created, updated or unchanged.",
+	}, []string{"result"})
+)
+
+// Handler serves the metrics in the Prometheus text format. overdue and
+// failing are called on every scrape for the number of feeds overdue for a
+// fetch and of feeds whose last fetch failed.
+func Handler(overdue, failing func() float64) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		FeedFetches,
+		FetchDuration,
+		DownloadedBytes,
+		SavedPosts,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "gator_feeds_overdue",
+			Help: "Enabled feeds due for a fetch that no aggregator is fetching.",
+		}, overdue),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "gator_feeds_failing",
+			Help: "Enabled feeds whose last fetch failed.",
+		}, failing),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,236 @@
+// Package opml reads and writes OPML subscription lists, the format feed
+// readers use to exchange the feeds a user follows.
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/net/html/charset"
+	"io"
+	"strings"
+	"time"
+)
+
+// Subscription is a single feed listed in an OPML document.
+type Subscription struct {
+	Title   string
+	FeedURL string
+	SiteURL string
+	// Folder is the slash-separated path of the outlines the feed is
+	// nested in, e.g. "Tech/Go". A slash or backslash within an outline's
+	// name is escaped with a backslash, so a folder named "News/Tech" is
+	// News\/Tech. Empty for top-level feeds.
+	Folder string
+}
+
+type document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title string `xml:"title"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []outline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type outline struct {
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Outlines []outline  `xml:"outline"`
+}
+
+// attr returns the value of the named attribute. Exporters disagree on the
+// casing of attributes such as xmlUrl, so the lookup ignores case.
+func (o outline) attr(name string) string {
+	for _, attr := range o.Attrs {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+func (o outline) title() string {
+	if title := o.attr("title"); title != "" {
+		return title
+	}
+	return o.attr("text")
+}
+
+// Parse reads an OPML 1.0 or 2.0 document and returns the subscriptions it
+// contains, flattening nested outlines into folders. Outlines that look like
+// feeds but have no URL are returned with an empty FeedURL so callers can
+// report them.
+func Parse(r io.Reader) ([]Subscription, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	var doc document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("couldn't decode OPML document: %w", err)
+	}
+
+	var subscriptions []Subscription
+	for _, o := range doc.Body.Outlines {
+		subscriptions = collect(subscriptions, o, nil)
+	}
+	return subscriptions, nil
+}
+
+func collect(subscriptions []Subscription, o outline, folders []string) []Subscription {
+	feedURL := o.attr("xmlUrl")
+	isFeed := feedURL != "" || isFeedType(o.attr("type"))
+	if isFeed {
+		folder := joinFolder(folders)
+		if folder == "" {
+			folder = categoryFolder(o.attr("category"))
+		}
+		subscriptions = append(subscriptions, Subscription{
+			Title:   o.title(),
+			FeedURL: feedURL,
+			SiteURL: o.attr("htmlUrl"),
+			Folder:  folder,
+		})
+	}
+
+	if len(o.Outlines) > 0 {
+		if !isFeed {
+			folders = append(folders[:len(folders):len(folders)], o.title())
+		}
+		for _, child := range o.Outlines {
+			subscriptions = collect(subscriptions, child, folders)
+		}
+	}
+	return subscriptions
+}
+
+func isFeedType(outlineType string) bool {
+	switch strings.ToLower(outlineType) {
+	case "rss", "atom", "feed":
+		return true
+	}
+	return false
+}
+
+// categoryFolder converts the first OPML 2.0 category, a comma-separated
+// list of slash-delimited paths, into a folder.
+func categoryFolder(category string) string {
+	first, _, _ := strings.Cut(category, ",")
+	return joinFolder(strings.Split(strings.Trim(strings.TrimSpace(first), "/"), "/"))
+}
+
+type outputDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []*outputOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type outputOutline struct {
+	Text     string           `xml:"text,attr"`
+	Title    string           `xml:"title,attr,omitempty"`
+	Type     string           `xml:"type,attr,omitempty"`
+	XMLURL   string           `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string           `xml:"htmlUrl,attr,omitempty"`
+	Outlines []*outputOutline `xml:"outline"`
+}
+
+// Write encodes subscriptions as an OPML 2.0 document, nesting feeds in
+// outlines that mirror their folders.
+func Write(w io.Writer, title string, created time.Time, subscriptions []Subscription) error {
+	var doc outputDocument
+	doc.Version = "2.0"
+	doc.Head.Title = title
+	doc.Head.DateCreated = created.Format(time.RFC1123Z)
+
+	folders := make(map[string]*outputOutline)
+	for _, sub := range subscriptions {
+		entry := &outputOutline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.FeedURL,
+			HTMLURL: sub.SiteURL,
+		}
+
+		if sub.Folder == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, entry)
+			continue
+		}
+		parent := folderOutline(&doc.Body.Outlines, folders, sub.Folder)
+		parent.Outlines = append(parent.Outlines, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("couldn't write OPML document: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("couldn't encode OPML document: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// folderOutline returns the outline for the folder path, creating it and
+// any missing parents on the way.
+func folderOutline(root *[]*outputOutline, folders map[string]*outputOutline, path string) *outputOutline {
+	if folder, ok := folders[path]; ok {
+		return folder
+	}
+
+	siblings := root
+	names := splitFolder(path)
+	name := names[len(names)-1]
+	if len(names) > 1 {
+		parent := folderOutline(root, folders, joinFolder(names[:len(names)-1]))
+		siblings = &parent.Outlines
+	}
+
+	folder := &outputOutline{Text: name, Title: name}
+	*siblings = append(*siblings, folder)
+	folders[path] = folder
+	return folder
+}
+
+var folderEscaper = strings.NewReplacer(`\`, `\\`, "/", `\/`)
+
+// joinFolder builds a folder path from the names of nested outlines,
+// escaping the separators within them.
+func joinFolder(names []string) string {
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		escaped[i] = folderEscaper.Replace(name)
+	}
+	return strings.Join(escaped, "/")
+}
+
+// splitFolder returns the names of the outlines of a folder path, the
+// inverse of joinFolder.
+func splitFolder(path string) []string {
+	var (
+		names []string
+		name  strings.Builder
+	)
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			name.WriteByte(path[i])
+		case path[i] == '/':
+			names = append(names, name.String())
+			name.Reset()
+		default:
+			name.WriteByte(path[i])
+		}
+	}
+	return append(names, name.String())
+}
@@ -0,0 +1,90 @@
+package opml
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "subscriptions.opml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	got, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	want := []Subscription{
+		{Title: "Top", FeedURL: "https://top.example.com/feed", SiteURL: "https://top.example.com/"},
+		{Title: "Go Blog", FeedURL: "https://go.dev/blog/feed.atom", Folder: "Tech"},
+		{Title: "Postgres", FeedURL: "https://postgres.example.com/rss", Folder: "Tech/Databases"},
+		// Feeds without a URL are kept so that they can be reported.
+		{Title: "Broken", Folder: "Tech"},
+		{Title: "Wire", FeedURL: "https://wire.example.com/atom", Folder: `News\/Tech`},
+		{Title: "Categorized", FeedURL: "https://cat.example.com/feed", Folder: "Reading/Blogs"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	subscriptions := []Subscription{
+		{Title: "Top", FeedURL: "https://top.example.com/feed", SiteURL: "https://top.example.com/"},
+		{Title: "Go & friends", FeedURL: "https://go.dev/blog/feed.atom", Folder: "Tech"},
+		{Title: "Postgres", FeedURL: "https://postgres.example.com/rss", Folder: "Tech/Databases"},
+		{Title: "SQLite", FeedURL: "https://sqlite.example.com/rss", Folder: "Tech/Databases"},
+		{Title: "Wire", FeedURL: "https://wire.example.com/atom", Folder: `News\/Tech`},
+		{Title: "Deep", FeedURL: "https://deep.example.com/atom", Folder: `News\/Tech/C:\\Temp\/Go`},
+	}
+
+	var buf bytes.Buffer
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := Write(&buf, "Subscriptions", created, subscriptions); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	document := buf.String()
+	for _, want := range []string{`<opml version="2.0">`, "<dateCreated>Fri, 01 Mar 2024 12:00:00 +0000</dateCreated>", `<outline text="News/Tech" title="News/Tech">`, `<outline text="C:\Temp/Go" title="C:\Temp/Go">`} {
+		if !strings.Contains(document, want) {
+			t.Errorf("Write output doesn't contain %s:\n%s", want, document)
+		}
+	}
+	if count := strings.Count(document, `text="Databases"`); count != 1 {
+		t.Errorf("Write output has %d Databases outlines, want 1:\n%s", count, document)
+	}
+
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, subscriptions) {
+		t.Errorf("Parse(Write(subscriptions)) =\n%+v\nwant\n%+v", got, subscriptions)
+	}
+}
+
+func TestSplitFolder(t *testing.T) {
+	tests := []struct {
+		path  string
+		names []string
+	}{
+		{"Tech", []string{"Tech"}},
+		{"Tech/Go", []string{"Tech", "Go"}},
+		{`News\/Tech`, []string{"News/Tech"}},
+		{`C:\\Temp/Go`, []string{`C:\Temp`, "Go"}},
+	}
+	for _, tt := range tests {
+		if got := splitFolder(tt.path); !reflect.DeepEqual(got, tt.names) {
+			t.Errorf("splitFolder(%q) = %q, want %q", tt.path, got, tt.names)
+		}
+		if got := joinFolder(tt.names); got != tt.path {
+			t.Errorf("joinFolder(%q) = %q, want %q", tt.names, got, tt.path)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// MinInterval is the shortest time allowed between two fetches of a feed.
+	MinInterval = 5 * time.Minute
+	// MaxInterval is the longest a feed may go without being fetched, unless
+	// it's configured with a longer interval.
+	MaxInterval = 24 * time.Hour
+	// MaxConfiguredInterval is the longest interval a feed may be given with
+	// setinterval.
+	MaxConfiguredInterval = 30 * 24 * time.Hour
+	// DefaultInterval is used when nothing is known about a feed yet.
+	DefaultInterval = time.Hour
+	// MaxBackoff caps the delay before retrying a failing feed.
+	MaxBackoff = 48 * time.Hour
+)
+
+// Hints gathers everything known about how often a feed should be polled.
+// Zero values mean the hint is unavailable.
+type Hints struct {
+	// Configured is the interval explicitly set for the feed by a user. It
+	// takes precedence over everything but publisher-imposed limits, which
+	// can stretch it up to MaxInterval.
+	Configured time.Duration
+	// TTL is the minimum interval declared by the feed itself.
+	TTL time.Duration
+	// Expires is when the last response stops being fresh, per HTTP caching
+	// headers.
+	Expires time.Time
+	// PostTimes are the publication dates of the feed's most recent posts.
+	PostTimes []time.Time
+}
+
+// Next returns when a feed should be fetched again given the hints.
+func Next(now time.Time, hints Hints) time.Time {
+	interval := hints.Configured
+	if interval <= 0 {
+		interval = observedInterval(now, hints.PostTimes)
+	}
+
+	// Publishers asking us to come back later always win, so we don't get
+	// rate-limited.
+	interval = max(interval, hints.TTL)
+	if !hints.Expires.IsZero() {
+		interval = max(interval, hints.Expires.Sub(now))
+	}
+
+	// Within limits though: a TTL or Expires far in the future mustn't stall
+	// the feed past its configured interval or MaxInterval, whichever is
+	// longer.
+	interval = max(interval, MinInterval)
+	interval = min(interval, max(hints.Configured, MaxInterval))
+	return now.Add(interval)
+}
+
+// Backoff returns when a feed that failed failures times in a row should be
+// retried. The delay doubles with each failure, starting at MinInterval, and
+// never undercuts a delay requested by the server.
+func Backoff(now time.Time, failures int, retryAfter time.Duration) time.Time {
+	delay := MaxBackoff
+	if failures < 1 {
+		failures = 1
+	}
+	// Beyond 2^10 * MinInterval the cap is reached anyway, and larger shifts
+	// would overflow.
+	if failures <= 10 {
+		delay = min(MinInterval<<(failures-1), MaxBackoff)
+	}
+	return now.Add(max(delay, retryAfter))
+}
+
+// observedInterval estimates a polling interval from the posting
+// frequency: half the median gap between recent posts, growing further for
+// feeds that haven't published in a long while.
+func observedInterval(now time.Time, postTimes []time.Time) time.Duration {
+	if len(postTimes) < 2 {
+		return DefaultInterval
+	}
+
+	times := make([]time.Time, len(postTimes))
+	copy(times, postTimes)
+	sort.Slice(times, func(i, j int) bool { return times[i].After(times[j]) })
+
+	gaps := make([]time.Duration, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		gaps = append(gaps, times[i-1].Sub(times[i]))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+
+	interval := gaps[len(gaps)/2] / 2
+	if silence := now.Sub(times[0]); silence > 0 {
+		interval = max(interval, silence/4)
+	}
+	return interval
+}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// every returns count post times, the latest one now, step apart.
+	every := func(step time.Duration, count int) []time.Time {
+		times := make([]time.Time, count)
+		for i := range times {
+			times[i] = now.Add(-time.Duration(i) * step)
+		}
+		return times
+	}
+	const year = 365 * 24 * time.Hour
+
+	tests := []struct {
+		name  string
+		hints Hints
+		want  time.Duration
+	}{
+		{"nothing known", Hints{}, DefaultInterval},
+		{"single post", Hints{PostTimes: every(time.Hour, 1)}, DefaultInterval},
+		{"configured", Hints{Configured: 2 * time.Hour}, 2 * time.Hour},
+		{"configured over MaxInterval", Hints{Configured: 72 * time.Hour}, 72 * time.Hour},
+		{"configured under MinInterval", Hints{Configured: time.Minute}, MinInterval},
+		{"configured over observed", Hints{Configured: 3 * time.Hour, PostTimes: every(time.Hour, 5)}, 3 * time.Hour},
+		{"observed", Hints{PostTimes: every(4*time.Hour, 5)}, 2 * time.Hour},
+		{"observed unsorted", Hints{PostTimes: []time.Time{now.Add(-8 * time.Hour), now, now.Add(-4 * time.Hour)}}, 2 * time.Hour},
+		{"observed under MinInterval", Hints{PostTimes: every(time.Minute, 5)}, MinInterval},
+		{"observed silence", Hints{PostTimes: []time.Time{now.Add(-12 * time.Hour), now.Add(-13 * time.Hour)}}, 3 * time.Hour},
+		{"observed over MaxInterval", Hints{PostTimes: every(10*24*time.Hour, 5)}, MaxInterval},
+		{"TTL over configured", Hints{Configured: time.Hour, TTL: 3 * time.Hour}, 3 * time.Hour},
+		{"TTL under configured", Hints{Configured: 3 * time.Hour, TTL: time.Hour}, 3 * time.Hour},
+		{"TTL over observed", Hints{TTL: 6 * time.Hour, PostTimes: every(4*time.Hour, 5)}, 6 * time.Hour},
+		{"Expires over configured", Hints{Configured: time.Hour, Expires: now.Add(2 * time.Hour)}, 2 * time.Hour},
+		{"Expires over TTL", Hints{TTL: 2 * time.Hour, Expires: now.Add(5 * time.Hour)}, 5 * time.Hour},
+		{"TTL over Expires", Hints{TTL: 5 * time.Hour, Expires: now.Add(2 * time.Hour)}, 5 * time.Hour},
+		{"Expires in the past", Hints{Configured: 2 * time.Hour, Expires: now.Add(-time.Hour)}, 2 * time.Hour},
+		{"TTL of a year", Hints{TTL: year}, MaxInterval},
+		{"TTL of a year, configured", Hints{Configured: 2 * time.Hour, TTL: year}, MaxInterval},
+		{"TTL of a year, configured over MaxInterval", Hints{Configured: 72 * time.Hour, TTL: year}, 72 * time.Hour},
+		{"Expires in a year, configured", Hints{Configured: 2 * time.Hour, Expires: now.Add(year)}, MaxInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Next(now, tt.hints).Sub(now); got != tt.want {
+				t.Errorf("Next(%+v) is in %v, want %v", tt.hints, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		failures   int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{0, 0, MinInterval},
+		{1, 0, MinInterval},
+		{2, 0, 2 * MinInterval},
+		{4, 0, 8 * MinInterval},
+		{10, 0, 512 * MinInterval},
+		{11, 0, MaxBackoff},
+		// Shifts this large would overflow without the cap.
+		{64, 0, MaxBackoff},
+		{1000, 0, MaxBackoff},
+		{1, 3 * time.Hour, 3 * time.Hour},
+		{4, time.Minute, 8 * MinInterval},
+		{11, 72 * time.Hour, 72 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(now, tt.failures, tt.retryAfter).Sub(now); got != tt.want {
+			t.Errorf("Backoff(%d, %v) is in %v, want %v", tt.failures, tt.retryAfter, got, tt.want)
+		}
+	}
+}
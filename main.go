@@ -1,19 +1,20 @@
 package main
 
 import (
-	"context"
 	"database/sql"
-	"fmt"
 	_ "github.com/lib/pq"
 	"github.com/peeta98/blog-aggregator/internal/config"
 	"github.com/peeta98/blog-aggregator/internal/database"
 	"log"
+	_ "modernc.org/sqlite"
 	"os"
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db      database.Store
+	dbConn  *sql.DB
+	backend backend
+	cfg     *config.Config
 }
 
 func main() {
@@ -22,34 +23,200 @@ func main() {
 		log.Fatal(err)
 	}
 
-	db, err := sql.Open("postgres", cfg.DbUrl)
+	db, backend, err := openDatabase(cfg)
 	if err != nil {
 		log.Fatalf("error connecting to db: %v", err)
 	}
 	defer db.Close()
-	dbQueries := database.New(db)
 
 	programState := &state{
-		cfg: &cfg,
-		db:  dbQueries,
+		cfg:     &cfg,
+		db:      backend.newStore(db),
+		dbConn:  db,
+		backend: backend,
 	}
 
 	cli := newCommands()
-	cli.register("login", handlerLogin)
-	cli.register("register", handlerRegister)
-	cli.register("reset", handlerReset)
-	cli.register("users", handlerListUsers)
-	cli.register("agg", handlerAggregate)
-	cli.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cli.register("feeds", handlerListFeeds)
-	cli.register("follow", middlewareLoggedIn(handlerFollowFeed))
-	cli.register("following", middlewareLoggedIn(handlerListFeedFollows))
-	cli.register("unfollow", middlewareLoggedIn(handlerUnfollowFeed))
-	cli.register("browse", middlewareLoggedIn(handlerBrowsePosts))
+	cli.register(commandSpec{
+		Name:            "help",
+		Summary:         "Show the available commands, or the usage of one",
+		Usage:           "[command]",
+		MaxArgs:         1,
+		SkipSchemaCheck: true,
+	}, cli.handlerHelp)
+	cli.register(commandSpec{
+		Name:            "migrate",
+		Summary:         "Apply, roll back or list the database migrations",
+		Usage:           "up|down|status|redo",
+		MinArgs:         1,
+		MaxArgs:         1,
+		SkipSchemaCheck: true,
+	}, handlerMigrate)
+	cli.register(commandSpec{
+		Name:    "login",
+		Summary: "Log in as a user, asking for their password if they have one",
+		Usage:   "<name>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerLogin)
+	cli.register(commandSpec{
+		Name:    "register",
+		Summary: "Create a user and log in as them",
+		Usage:   "<name>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerRegister)
+	cli.register(commandSpec{
+		Name:    "logout",
+		Summary: "End the current session",
+	}, handlerLogout)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "passwd",
+		Summary: "Set, change or remove your password",
+	}, handlerPasswd)
+	cli.register(commandSpec{
+		Name:    "reset",
+		Summary: "Delete every user and their feeds",
+	}, handlerReset)
+	cli.register(commandSpec{
+		Name:    "users",
+		Summary: "List the registered users",
+	}, handlerListUsers)
+	cli.register(commandSpec{
+		Name:    "agg",
+		Summary: "Collect due feeds continuously, or every feed once",
+		Usage:   "[time_between_reqs]",
+		MaxArgs: 1,
+		Flags:   aggregateFlags,
+	}, handlerAggregate)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "apikey",
+		Summary: "Create, list or revoke your API keys",
+		Usage:   "create [name] | list | revoke <key_id>",
+		MinArgs: 1,
+		MaxArgs: 2,
+	}, handlerAPIKey)
+	cli.register(commandSpec{
+		Name:    "serve",
+		Summary: "Serve the JSON REST API",
+		Flags:   serveFlags,
+	}, handlerServe)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "addfeed",
+		Summary: "Add a feed, or discover one from a website, and follow it",
+		Usage:   "[name] <url>",
+		MinArgs: 1,
+		MaxArgs: 2,
+	}, handlerAddFeed)
+	cli.register(commandSpec{
+		Name:    "feeds",
+		Summary: "List every feed",
+	}, handlerListFeeds)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "setinterval",
+		Summary: "Set how often a feed you added is fetched",
+		Usage:   "<feed_url> <interval|auto>",
+		MinArgs: 2,
+		MaxArgs: 2,
+	}, handlerSetFeedInterval)
+	cli.register(commandSpec{
+		Name:    "feedstatus",
+		Summary: "List the feeds that are failing or disabled",
+	}, handlerFeedStatus)
+	cli.register(commandSpec{
+		Name:    "enablefeed",
+		Summary: "Re-enable a feed disabled after repeated failures",
+		Usage:   "<feed_url>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerEnableFeed)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "follow",
+		Summary: "Follow an existing feed",
+		Usage:   "<feed_url>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerFollowFeed)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "following",
+		Summary: "List the feeds you follow",
+	}, handlerListFeedFollows)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "unfollow",
+		Summary: "Stop following a feed",
+		Usage:   "<feed_url>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerUnfollowFeed)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "browse",
+		Summary: "Browse the posts of the feeds you follow",
+		Usage:   "[limit]",
+		MaxArgs: 1,
+		Flags:   browseFlags,
+	}, handlerBrowsePosts)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "read",
+		Summary: "Mark a post as read",
+		Usage:   "<post_id>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerReadPost)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "unread",
+		Summary: "Mark a post as unread",
+		Usage:   "<post_id>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerUnreadPost)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "markread",
+		Summary: "Mark many posts as read at once",
+		Flags:   markReadFlags,
+	}, handlerMarkRead)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "star",
+		Summary: "Star a post",
+		Usage:   "<post_id>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerStarPost)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "unstar",
+		Summary: "Remove the star from a post",
+		Usage:   "<post_id>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerUnstarPost)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "starred",
+		Summary: "List your starred posts",
+	}, handlerListStarredPosts)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "search",
+		Summary: "Search the posts of the feeds you follow",
+		Usage:   "<query>",
+		MinArgs: 1,
+		MaxArgs: -1,
+		Flags:   searchFlags,
+	}, handlerSearchPosts)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "import",
+		Summary: "Add and follow the feeds of an OPML file",
+		Usage:   "<file.opml>",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlerImport)
+	cli.registerLoggedIn(commandSpec{
+		Name:    "export",
+		Summary: "Write the feeds you follow as OPML",
+		Usage:   "[file.opml]",
+		MaxArgs: 1,
+	}, handlerExport)
 
 	if len(os.Args) < 2 {
-		log.Fatal("Usage: cli <command> [args...]")
-		return
+		cli.run(programState, command{Name: "help"})
+		os.Exit(1)
 	}
 
 	cmd := command{
@@ -64,9 +231,9 @@ func main() {
 
 func middlewareLoggedIn(handler authenticatedCommandHandler) commandHandler {
 	return func(state *state, cmd command) error {
-		user, err := state.db.GetUser(context.Background(), state.cfg.CurrentUserName)
+		user, err := currentUser(state)
 		if err != nil {
-			return fmt.Errorf("failed to get authenticated user: %v", err)
+			return err
 		}
 
 		return handler(state, cmd, user)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/metrics"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"time"
+)
+
+// serveMetrics serves the metrics of the aggregator on addr until the
+// returned function is called.
+func serveMetrics(s *state, addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't listen for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler(
+		countFeeds("overdue", func(ctx context.Context) (int64, error) {
+			return s.db.CountOverdueFeeds(ctx, time.Now().UTC())
+		}),
+		countFeeds("failing", s.db.CountFailingFeeds),
+	))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Couldn't serve metrics: %v", err)
+		}
+	}()
+	log.Printf("Serving metrics on http://%s/metrics", listener.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
+
+// countFeeds turns a query counting feeds into the value of a gauge, which
+// is NaN when the query fails.
+func countFeeds(kind string, query func(ctx context.Context) (int64, error)) func() float64 {
+	return func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		count, err := query(ctx)
+		if err != nil {
+			log.Printf("Couldn't count %s feeds: %v", kind, err)
+			return math.NaN()
+		}
+		return float64(count)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"github.com/pressly/goose/v3"
+	"io/fs"
+)
+
+//go:embed sql/schema/*.sql sql/sqlite/schema/*.sql
+var embeddedMigrations embed.FS
+
+func newMigrationProvider(s *state) (*goose.Provider, error) {
+	migrations, err := fs.Sub(embeddedMigrations, s.backend.migrations)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := goose.NewProvider(s.backend.dialect, s.dbConn, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// checkSchemaVersion makes sure the database has every migration this
+// binary was built with, so that commands don't fail halfway through on a
+// missing table or column.
+func checkSchemaVersion(s *state) error {
+	provider, err := newMigrationProvider(s)
+	if err != nil {
+		return err
+	}
+
+	current, target, err := provider.GetVersions(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't get database schema version: %w", err)
+	}
+	if current < target {
+		return fmt.Errorf("database schema is at version %d but this version of blog-aggregator needs %d, run 'blog-aggregator migrate up' first", current, target)
+	}
+	return nil
+}
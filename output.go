@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+	outputTable  = "table"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputNDJSON, outputCSV, outputTable}
+
+// record is a row of a listing command's output. The JSON encoding of a
+// record is its documented structure; header and row give the same fields as
+// columns for the csv and table formats.
+type record interface {
+	header() []string
+	row() []string
+}
+
+func validateOutputFormat(format string) error {
+	for _, f := range outputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format %q: must be one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// render writes records to stdout in the format selected with --output. The
+// text format is the human readable one each command prints itself.
+func render[T record](cmd command, records []T, text func()) error {
+	switch cmd.stringFlag("output") {
+	case outputJSON:
+		if records == nil {
+			records = []T{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case outputNDJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		for _, r := range records {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputCSV:
+		w := csv.NewWriter(os.Stdout)
+		var zero T
+		w.Write(zero.header())
+		for _, r := range records {
+			w.Write(r.row())
+		}
+		w.Flush()
+		return w.Error()
+	case outputTable:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		var zero T
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(zero.header(), "\t")))
+		for _, r := range records {
+			cells := r.row()
+			for i, cell := range cells {
+				// Keep every record on one line of the table
+				cells[i] = strings.Join(strings.Fields(cell), " ")
+			}
+			fmt.Fprintln(w, strings.Join(cells, "\t"))
+		}
+		return w.Flush()
+	default:
+		text()
+		return nil
+	}
+}
+
+func timeOrNil(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
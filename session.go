@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const sessionDuration = 30 * 24 * time.Hour
+
+// stdin is shared by every prompt so that buffered input isn't lost between
+// them when it is piped in.
+var stdin = bufio.NewReader(os.Stdin)
+
+// currentUser resolves the logged in user from the session token in the
+// config. Configs written before sessions existed only hold a user name,
+// which is still honoured for users without a password.
+func currentUser(s *state) (database.User, error) {
+	if s.cfg.SessionToken != "" {
+		user, err := s.db.GetUserBySession(context.Background(), database.GetUserBySessionParams{
+			TokenHash: hashToken(s.cfg.SessionToken),
+			ExpiresAt: time.Now().UTC(),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, errors.New("session expired, log in again")
+		}
+		if err != nil {
+			return database.User{}, fmt.Errorf("failed to get authenticated user: %v", err)
+		}
+		return user, nil
+	}
+
+	user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUserName)
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to get authenticated user: %v", err)
+	}
+	if user.PasswordHash.Valid {
+		return database.User{}, fmt.Errorf("user %s has a password, log in first", user.Name)
+	}
+	return user, nil
+}
+
+// startSession issues a session token for user and saves it in the config,
+// ending the session it replaces.
+func startSession(s *state, user database.User) error {
+	if s.cfg.SessionToken != "" {
+		if err := s.db.DeleteSession(context.Background(), hashToken(s.cfg.SessionToken)); err != nil {
+			return fmt.Errorf("couldn't end previous session: %w", err)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("couldn't generate session token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	_, err := s.db.CreateSession(context.Background(), database.CreateSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(sessionDuration),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create session: %w", err)
+	}
+
+	if err := s.cfg.SetSession(user.Name, token); err != nil {
+		return fmt.Errorf("couldn't set current user: %w", err)
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// promptNewPassword asks for a password twice. An empty password means the
+// user doesn't want one.
+func promptNewPassword() (sql.NullString, error) {
+	password, err := readPassword("Password (leave empty for none): ")
+	if err != nil || password == "" {
+		return sql.NullString{}, err
+	}
+	confirmation, err := readPassword("Confirm password: ")
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if password != confirmation {
+		return sql.NullString{}, errors.New("passwords don't match")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("couldn't hash password: %w", err)
+	}
+	return sql.NullString{String: string(hash), Valid: true}, nil
+}
+
+// checkPassword prompts for the password of user, if they have one.
+func checkPassword(user database.User) error {
+	if !user.PasswordHash.Valid {
+		return nil
+	}
+	password, err := readPassword("Password: ")
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash.String), []byte(password)) != nil {
+		return errors.New("invalid password")
+	}
+	return nil
+}
+
+// readPassword reads a line from stdin without echoing it when stdin is a
+// terminal. Piped input is read as is, for scripts, and empty input is an
+// empty password.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("couldn't read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("couldn't read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
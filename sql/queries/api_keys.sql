@@ -0,0 +1,25 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, created_at, user_id, name, prefix, key_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAPIKeysForUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetUserByAPIKey :one
+-- Resolves the owner of a key that hasn't been revoked.
+SELECT users.* FROM users
+JOIN api_keys ON api_keys.user_id = users.id
+WHERE api_keys.key_hash = $1 AND api_keys.revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = $2
+WHERE key_hash = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = $3
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
@@ -1,7 +1,7 @@
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
+    VALUES ($1, $2, $3, $4, $5, $6)
     RETURNING *
 )
 SELECT
@@ -16,7 +16,14 @@ INNER JOIN users ON inserted_feed_follow.user_id = users.id;
 SELECT
     f_f.*,
     u.name AS user_name,
-    f.name AS feed_name
+    f.name AS feed_name,
+    f.url AS feed_url,
+    f.site_url AS feed_site_url,
+    (
+        SELECT COUNT(*) FROM posts p
+        LEFT JOIN post_reads p_r ON p_r.post_id = p.id AND p_r.user_id = f_f.user_id
+        WHERE p.feed_id = f_f.feed_id AND p_r.read_at IS NULL
+    ) AS unread_count
 FROM feed_follows f_f
 INNER JOIN feeds f ON f_f.feed_id = f.id
 INNER JOIN users u ON f_f.user_id = u.id
@@ -25,3 +32,7 @@ WHERE f_f.user_id = $1;
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
 WHERE feed_id = $1 AND user_id = $2;
+
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
@@ -17,14 +17,126 @@ SELECT * FROM feeds;
 SELECT * FROM feeds
 WHERE url = $1;
 
--- name: GetNextFeedToFetch :one
+-- name: GetNextFeedsToFetch :many
+-- Claims the feeds that have been due the longest by leasing them to the
+-- caller, so that other aggregators skip them until the lease is released
+-- or expires.
+UPDATE feeds
+SET last_fetched_at = sqlc.arg(now)::timestamp,
+    updated_at = sqlc.arg(now)::timestamp,
+    lease_owner = sqlc.arg(lease_owner)::text,
+    lease_expires_at = sqlc.arg(now)::timestamp + sqlc.arg(lease_seconds)::integer * INTERVAL '1 second'
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::timestamp)
+      AND (lease_expires_at IS NULL OR lease_expires_at <= sqlc.arg(now)::timestamp)
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT sqlc.arg(feed_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimFeed :one
+-- Leases a feed to the caller for a fetch outside of the schedule. No row is
+-- returned while another aggregator holds the lease.
+UPDATE feeds
+SET last_fetched_at = sqlc.arg(now)::timestamp,
+    updated_at = sqlc.arg(now)::timestamp,
+    lease_owner = sqlc.arg(lease_owner)::text,
+    lease_expires_at = sqlc.arg(now)::timestamp + sqlc.arg(lease_seconds)::integer * INTERVAL '1 second'
+WHERE id = sqlc.arg(id)
+    AND (lease_expires_at IS NULL OR lease_expires_at <= sqlc.arg(now)::timestamp OR lease_owner = sqlc.arg(lease_owner)::text)
+RETURNING *;
+
+-- name: CountOverdueFeeds :one
+-- Counts the enabled feeds that are due for a fetch and that no aggregator
+-- is fetching.
+SELECT COUNT(*) FROM feeds
+WHERE disabled_at IS NULL
+  AND (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::timestamp)
+  AND (lease_expires_at IS NULL OR lease_expires_at <= sqlc.arg(now)::timestamp);
+
+-- name: CountFailingFeeds :one
+-- Counts the enabled feeds whose last fetch failed.
+SELECT COUNT(*) FROM feeds
+WHERE disabled_at IS NULL AND consecutive_failures > 0;
+
+-- name: ReleaseFeedLease :exec
+-- Gives up the lease on a feed without scheduling its next fetch, so that
+-- any aggregator can fetch it again right away.
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2;
+
+-- name: UpdateFeedHTTPCache :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = $4
+WHERE id = $1;
+
+-- name: UpdateFeedNextFetch :exec
+-- Schedules the next fetch of a feed and releases the lease on it.
+UPDATE feeds
+SET next_fetch_at = $2,
+    lease_owner = NULL,
+    lease_expires_at = NULL,
+    updated_at = $3
+WHERE id = $1;
+
+-- name: UpdateFeedSiteURL :exec
+-- Records the address of the website a feed belongs to, as its document
+-- links to it.
+UPDATE feeds
+SET site_url = $2,
+    updated_at = $3
+WHERE id = $1;
+
+-- name: UpdateFeedFetchInterval :one
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+    next_fetch_at = NULL,
+    updated_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: GetFailingFeeds :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at DESC NULLS LAST, consecutive_failures DESC;
+
+-- name: RecordFeedFailure :one
+UPDATE feeds
+SET last_error = sqlc.arg(last_error),
+    last_status_code = sqlc.arg(last_status_code),
+    last_error_at = sqlc.arg(now)::timestamp,
+    consecutive_failures = consecutive_failures + 1,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_status_code = $2,
+    consecutive_failures = 0,
+    updated_at = $3
+WHERE id = $1;
+
+-- name: DisableFeed :exec
+UPDATE feeds
+SET disabled_at = sqlc.arg(now)::timestamp,
+    lease_owner = NULL,
+    lease_expires_at = NULL,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE id = sqlc.arg(id);
 
--- name: MarkFeedFetched :one
+-- name: EnableFeed :one
 UPDATE feeds
-SET last_fetched_at = NOW(),
-    updated_at = NOW()
+SET disabled_at = NULL,
+    consecutive_failures = 0,
+    next_fetch_at = NULL,
+    updated_at = $2
 WHERE id = $1
 RETURNING *;
@@ -0,0 +1,37 @@
+-- name: MarkPostRead :execrows
+-- Affects a row, keeping the first read time, when the post was already
+-- read: none are affected only when the post isn't in the user's feeds.
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, sqlc.arg(read_at)::timestamp
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = sqlc.arg(user_id) AND p.id = sqlc.arg(post_id)
+ON CONFLICT (user_id, post_id) DO UPDATE SET read_at = post_reads.read_at;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkFeedPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, sqlc.arg(read_at)::timestamp
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = sqlc.arg(user_id) AND p.feed_id = sqlc.arg(feed_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostsReadBefore :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, sqlc.arg(read_at)::timestamp
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = sqlc.arg(user_id) AND p.published_at < sqlc.arg(published_before)::timestamp
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkAllPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT f_f.user_id, p.id, sqlc.arg(read_at)::timestamp
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+WHERE f_f.user_id = sqlc.arg(user_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
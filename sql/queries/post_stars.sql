@@ -0,0 +1,21 @@
+-- name: StarPost :execrows
+-- Copies the post into the star, so that it outlives the post. Starring a
+-- starred post affects its row: none are affected only when the post doesn't
+-- exist or isn't in a feed the user follows.
+INSERT INTO post_stars (user_id, post_id, starred_at, title, url, description, published_at, fetched_at, feed_name)
+SELECT f_f.user_id, p.id, sqlc.arg(starred_at)::timestamp, p.title, p.url, p.description, p.published_at, p.created_at, f.name
+FROM posts p
+JOIN feeds f ON f.id = p.feed_id
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id AND f_f.user_id = sqlc.arg(user_id)
+WHERE p.id = sqlc.arg(post_id)
+ON CONFLICT (user_id, post_id) DO UPDATE SET starred_at = post_stars.starred_at;
+
+-- name: UnstarPost :execrows
+DELETE FROM post_stars
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetStarredPostsForUser :many
+SELECT p_s.post_id, p_s.title, p_s.url, p_s.description, p_s.published_at, p_s.fetched_at, p_s.feed_name, p_r.read_at FROM post_stars p_s
+LEFT JOIN post_reads p_r ON p_r.post_id = p_s.post_id AND p_r.user_id = p_s.user_id
+WHERE p_s.user_id = $1
+ORDER BY p_s.starred_at DESC;
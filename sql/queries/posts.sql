@@ -1,12 +1,47 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING *;
+-- name: UpsertPost :one
+-- Inserts a new post or refreshes an existing one with the same GUID. No row
+-- is returned when the stored post is already up to date.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    published_at = COALESCE(posts.published_at, EXCLUDED.published_at),
+    updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR posts.content IS DISTINCT FROM EXCLUDED.content
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content;
 
--- name: GetPostsForUser :many
-SELECT p.*, f.name AS feed_name FROM posts p
+-- name: GetFollowedPostID :one
+-- Finds a post among the feeds the user follows.
+SELECT p.id FROM posts p
 JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
-JOIN feeds f ON f.id = p.feed_id
-WHERE f_f.user_id = $1
-ORDER BY p.published_at DESC
+WHERE f_f.user_id = $1 AND p.id = $2;
+
+-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
 LIMIT $2;
+
+-- name: SearchPostsForUser :many
+-- Full-text search over the posts of feeds the user follows, best matches
+-- first. The query uses web search syntax: quoted phrases, "or" and -exclude.
+SELECT p.id, p.title, p.url, p.published_at, f.name AS feed_name, f.url AS feed_url,
+    ts_rank(p.search_vector, tsq) AS rank,
+    ts_headline('english', coalesce(p.description, p.content, ''), tsq,
+        'StartSel=**, StopSel=**, MaxWords=30, MinWords=10, MaxFragments=2')::text AS snippet
+FROM posts p
+JOIN feed_follows f_f ON f_f.feed_id = p.feed_id
+JOIN feeds f ON f.id = p.feed_id
+CROSS JOIN websearch_to_tsquery('english', sqlc.arg(query)) tsq
+WHERE f_f.user_id = sqlc.arg(user_id)
+    AND p.search_vector @@ tsq
+    AND (sqlc.narg(feed)::text IS NULL OR f.url = sqlc.narg(feed) OR f.name = sqlc.narg(feed))
+    AND (sqlc.narg(since)::timestamp IS NULL OR p.published_at >= sqlc.narg(since))
+ORDER BY rank DESC, p.published_at DESC NULLS LAST
+LIMIT sqlc.arg(post_limit);
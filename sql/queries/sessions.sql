@@ -0,0 +1,18 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, created_at, expires_at, user_id, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetUserBySession :one
+-- Resolves the user a session token was issued to, unless it has expired.
+SELECT users.* FROM users
+JOIN sessions ON sessions.user_id = users.id
+WHERE sessions.token_hash = $1 AND sessions.expires_at > $2;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = $1;
+
+-- name: DeleteUserSessions :exec
+DELETE FROM sessions
+WHERE user_id = $1;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN next_fetch_at TIMESTAMP,
+ADD COLUMN fetch_interval_seconds INTEGER;
+
+CREATE INDEX idx_feeds_next_fetch_at ON feeds (next_fetch_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_feeds_next_fetch_at;
+
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS next_fetch_at,
+DROP COLUMN IF EXISTS fetch_interval_seconds;
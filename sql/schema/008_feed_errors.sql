@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN last_error TEXT,
+ADD COLUMN last_error_at TIMESTAMP,
+ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN last_status_code INTEGER,
+ADD COLUMN disabled_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS last_error,
+DROP COLUMN IF EXISTS last_error_at,
+DROP COLUMN IF EXISTS consecutive_failures,
+DROP COLUMN IF EXISTS last_status_code,
+DROP COLUMN IF EXISTS disabled_at;
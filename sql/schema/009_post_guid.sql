@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN guid TEXT;
+
+UPDATE posts SET guid = url;
+
+ALTER TABLE posts
+ALTER COLUMN guid SET NOT NULL,
+DROP CONSTRAINT IF EXISTS posts_url_key,
+ADD CONSTRAINT unique_feed_guid UNIQUE (feed_id, guid);
+
+-- +goose Down
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS unique_feed_guid,
+DROP COLUMN IF EXISTS guid,
+ADD CONSTRAINT posts_url_key UNIQUE (url);
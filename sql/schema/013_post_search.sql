@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content TEXT,
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(content, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_posts_search_vector ON posts USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_posts_search_vector;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS search_vector,
+DROP COLUMN IF EXISTS content;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX idx_posts_feed_published ON posts (feed_id, published_at DESC NULLS LAST);
+CREATE INDEX idx_posts_feed_created ON posts (feed_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_posts_feed_created;
+DROP INDEX IF EXISTS idx_posts_feed_published;
@@ -0,0 +1,44 @@
+-- +goose Up
+-- Stars keep a copy of their post, so that they outlive it when its feed
+-- goes away.
+ALTER TABLE post_stars
+ADD COLUMN title TEXT,
+ADD COLUMN url TEXT,
+ADD COLUMN description TEXT,
+ADD COLUMN published_at TIMESTAMP,
+ADD COLUMN fetched_at TIMESTAMP,
+ADD COLUMN feed_name TEXT;
+
+UPDATE post_stars p_s
+SET title = p.title,
+    url = p.url,
+    description = p.description,
+    published_at = p.published_at,
+    fetched_at = p.created_at,
+    feed_name = f.name
+FROM posts p
+JOIN feeds f ON f.id = p.feed_id
+WHERE p.id = p_s.post_id;
+
+ALTER TABLE post_stars
+ALTER COLUMN title SET NOT NULL,
+ALTER COLUMN url SET NOT NULL,
+ALTER COLUMN fetched_at SET NOT NULL,
+ALTER COLUMN feed_name SET NOT NULL,
+DROP CONSTRAINT fk_post;
+
+-- +goose Down
+DELETE FROM post_stars
+WHERE post_id NOT IN (SELECT id FROM posts);
+
+ALTER TABLE post_stars
+DROP COLUMN title,
+DROP COLUMN url,
+DROP COLUMN description,
+DROP COLUMN published_at,
+DROP COLUMN fetched_at,
+DROP COLUMN feed_name,
+ADD CONSTRAINT fk_post
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE;
@@ -0,0 +1,133 @@
+-- +goose Up
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL UNIQUE,
+    password_hash TEXT
+);
+
+CREATE TABLE feeds (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL UNIQUE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_fetched_at TIMESTAMP,
+    etag TEXT,
+    last_modified TEXT,
+    next_fetch_at TIMESTAMP,
+    fetch_interval_seconds INTEGER,
+    last_error TEXT,
+    last_error_at TIMESTAMP,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    disabled_at TIMESTAMP
+);
+
+-- SQLite sorts NULLs first in ascending order and last in descending order,
+-- which is what the queries ask for
+CREATE INDEX idx_feeds_next_fetch_at ON feeds (next_fetch_at);
+
+CREATE TABLE feed_follows (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    folder TEXT,
+    CONSTRAINT unique_user_feed UNIQUE (user_id, feed_id)
+);
+
+CREATE TABLE posts (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    content TEXT,
+    CONSTRAINT unique_feed_guid UNIQUE (feed_id, guid)
+);
+
+CREATE INDEX idx_posts_feed_published ON posts (feed_id, published_at DESC);
+CREATE INDEX idx_posts_feed_created ON posts (feed_id, created_at DESC);
+
+-- The full-text index of posts, kept in sync by the triggers below. It holds
+-- its own copy of the text so that it doesn't depend on the rowids of posts.
+CREATE VIRTUAL TABLE posts_fts USING fts5(
+    post_id UNINDEXED,
+    title,
+    description,
+    content,
+    tokenize = 'porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (post_id, title, description, content)
+    VALUES (new.id, new.title, new.description, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, description, content ON posts BEGIN
+    UPDATE posts_fts
+    SET title = new.title, description = new.description, content = new.content
+    WHERE post_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE post_id = old.id;
+END;
+-- +goose StatementEnd
+
+CREATE TABLE post_reads (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE post_stars (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    starred_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE sessions (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE
+);
+
+-- +goose Down
+DROP TABLE sessions;
+DROP TABLE api_keys;
+DROP TABLE post_stars;
+DROP TABLE post_reads;
+DROP TABLE posts_fts;
+DROP TABLE posts;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Stars keep a copy of their post, so that they outlive it when its feed
+-- goes away. SQLite can't drop a foreign key, so the table is rebuilt.
+CREATE TABLE post_star_snapshots (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL,
+    starred_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    fetched_at TIMESTAMP NOT NULL,
+    feed_name TEXT NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+INSERT INTO post_star_snapshots
+SELECT p_s.user_id, p_s.post_id, p_s.starred_at, p.title, p.url, p.description, p.published_at, p.created_at, f.name
+FROM post_stars p_s
+JOIN posts p ON p.id = p_s.post_id
+JOIN feeds f ON f.id = p.feed_id;
+
+DROP TABLE post_stars;
+ALTER TABLE post_star_snapshots RENAME TO post_stars;
+
+-- +goose Down
+CREATE TABLE post_stars_by_post (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    starred_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+INSERT INTO post_stars_by_post
+SELECT user_id, post_id, starred_at FROM post_stars
+WHERE post_id IN (SELECT id FROM posts);
+
+DROP TABLE post_stars;
+ALTER TABLE post_stars_by_post RENAME TO post_stars;
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/peeta98/blog-aggregator/internal/config"
+	"github.com/peeta98/blog-aggregator/internal/database"
+	"github.com/peeta98/blog-aggregator/internal/database/sqlite"
+	"github.com/pressly/goose/v3"
+)
+
+// backend is a kind of database db_url can point at: the driver to open it
+// with, the implementation of its queries and its migrations.
+type backend struct {
+	driver     string
+	dialect    goose.Dialect
+	migrations string
+	newStore   func(db database.DBTX) database.Store
+}
+
+var backends = map[string]backend{
+	config.Postgres: {
+		driver:     "postgres",
+		dialect:    goose.DialectPostgres,
+		migrations: "sql/schema",
+		newStore:   func(db database.DBTX) database.Store { return database.NewStore(db) },
+	},
+	config.SQLite: {
+		driver:     "sqlite",
+		dialect:    goose.DialectSQLite3,
+		migrations: "sql/sqlite/schema",
+		newStore:   func(db database.DBTX) database.Store { return sqlite.New(db) },
+	},
+}
+
+// openDatabase connects to the database selected by the scheme of db_url.
+func openDatabase(cfg config.Config) (*sql.DB, backend, error) {
+	name, dsn, err := cfg.Database()
+	if err != nil {
+		return nil, backend{}, err
+	}
+	b := backends[name]
+
+	if name == config.SQLite {
+		// Cascading deletes need foreign keys, which SQLite leaves off by
+		// default, and the time format keeps timestamps comparable as text
+		dsn = "file:" + dsn + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	}
+
+	db, err := sql.Open(b.driver, dsn)
+	if err != nil {
+		return nil, backend{}, fmt.Errorf("couldn't open database: %w", err)
+	}
+	if name == config.SQLite {
+		// SQLite has a single writer: share one connection instead of having
+		// concurrent writes wait for the lock
+		db.SetMaxOpenConns(1)
+	}
+	return db, b, nil
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/peeta98/blog-aggregator/internal/config"
+	"github.com/peeta98/blog-aggregator/internal/database"
+)
+
+// postgresTestURL names the environment variable pointing the tests at a
+// PostgreSQL database. The tests drop every table in it, so it must be one
+// made for them.
+const postgresTestURL = "GATOR_TEST_POSTGRES_URL"
+
+// forEachBackend runs test on a freshly migrated database of each backend:
+// SQLite always, PostgreSQL when postgresTestURL is set.
+func forEachBackend(t *testing.T, test func(t *testing.T, s *state)) {
+	t.Helper()
+	t.Run(config.SQLite, func(t *testing.T) {
+		test(t, openTestDatabase(t, "sqlite://"+filepath.Join(t.TempDir(), "gator.db")))
+	})
+	t.Run(config.Postgres, func(t *testing.T) {
+		dbURL := os.Getenv(postgresTestURL)
+		if dbURL == "" {
+			t.Skip(postgresTestURL + " isn't set")
+		}
+		test(t, openTestDatabase(t, dbURL))
+	})
+}
+
+// openTestDatabase connects to dbURL and migrates it from scratch.
+func openTestDatabase(t *testing.T, dbURL string) *state {
+	t.Helper()
+	conn, b, err := openDatabase(config.Config{DbUrl: dbURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s := &state{db: b.newStore(conn), dbConn: conn, backend: b}
+	provider, err := newMigrationProvider(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := provider.DownTo(ctx, 0); err != nil {
+		t.Fatalf("couldn't roll back migrations: %v", err)
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		t.Fatalf("couldn't apply migrations: %v", err)
+	}
+	return s
+}
+
+func createTestUser(t *testing.T, db database.Store, name string) database.User {
+	t.Helper()
+	now := time.Now().UTC()
+	user, err := db.CreateUser(context.Background(), database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatalf("couldn't create user %s: %v", name, err)
+	}
+	return user
+}
+
+// createTestFeed creates a feed added by user, who follows it.
+func createTestFeed(t *testing.T, db database.Store, user database.User, name, url string) database.Feed {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	feed, err := db.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+		Url:       url,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatalf("couldn't create feed %s: %v", name, err)
+	}
+	followTestFeed(t, db, user, feed)
+	return feed
+}
+
+func followTestFeed(t *testing.T, db database.Store, user database.User, feed database.Feed) {
+	t.Helper()
+	now := time.Now().UTC()
+	_, err := db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		t.Fatalf("couldn't follow feed %s: %v", feed.Name, err)
+	}
+}
+
+// createTestPost adds a post to feed, published at published unless it is
+// the zero time.
+func createTestPost(t *testing.T, db database.Store, feed database.Feed, title, content string, published time.Time) database.UpsertPostRow {
+	t.Helper()
+	now := time.Now().UTC()
+	post, err := db.UpsertPost(context.Background(), database.UpsertPostParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Title:       title,
+		Url:         feed.Url + "/" + title,
+		PublishedAt: sql.NullTime{Time: published, Valid: !published.IsZero()},
+		FeedID:      feed.ID,
+		Guid:        title,
+		Content:     sql.NullString{String: content, Valid: content != ""},
+	})
+	if err != nil {
+		t.Fatalf("couldn't create post %s: %v", title, err)
+	}
+	return post
+}
+
+func TestStoreMigrations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		provider, err := newMigrationProvider(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		current, target, err := provider.GetVersions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current != target {
+			t.Fatalf("schema is at version %d after migrating, want %d", current, target)
+		}
+		if err := checkSchemaVersion(s); err != nil {
+			t.Errorf("checkSchemaVersion returned error: %v", err)
+		}
+
+		// Every migration must roll back, and apply again on top of data.
+		user := createTestUser(t, s.db, "alice")
+		feed := createTestFeed(t, s.db, user, "Feed", "https://example.com/feed")
+		createTestPost(t, s.db, feed, "post", "", time.Now().UTC())
+		if _, err := provider.DownTo(ctx, 0); err != nil {
+			t.Fatalf("couldn't roll back migrations: %v", err)
+		}
+		if err := checkSchemaVersion(s); err == nil {
+			t.Error("checkSchemaVersion accepted a database without the schema")
+		}
+		if _, err := provider.Up(ctx); err != nil {
+			t.Fatalf("couldn't apply migrations again: %v", err)
+		}
+		createTestUser(t, s.db, "alice")
+	})
+}
+
+func TestStoreConflict(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		now := time.Now().UTC()
+		user := createTestUser(t, s.db, "alice")
+		feed := createTestFeed(t, s.db, user, "Feed", "https://example.com/feed")
+		key := database.CreateAPIKeyParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UserID:    user.ID,
+			Name:      "key",
+			Prefix:    "gator_ab",
+			KeyHash:   "hash",
+		}
+		if _, err := s.db.CreateAPIKey(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := s.db.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "alice"})
+		if !errors.Is(err, database.ErrConflict) {
+			t.Errorf("CreateUser with a taken name returned %v, want ErrConflict", err)
+		}
+		_, err = s.db.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Other", Url: feed.Url, UserID: user.ID})
+		if !errors.Is(err, database.ErrConflict) {
+			t.Errorf("CreateFeed with a taken URL returned %v, want ErrConflict", err)
+		}
+		_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID})
+		if !errors.Is(err, database.ErrConflict) {
+			t.Errorf("CreateFeedFollow of a followed feed returned %v, want ErrConflict", err)
+		}
+		key.ID = uuid.New()
+		if _, err := s.db.CreateAPIKey(ctx, key); !errors.Is(err, database.ErrConflict) {
+			t.Errorf("CreateAPIKey with a taken hash returned %v, want ErrConflict", err)
+		}
+
+		// Other constraint violations aren't conflicts.
+		_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: uuid.New()})
+		if err == nil || errors.Is(err, database.ErrConflict) {
+			t.Errorf("CreateFeedFollow of a missing feed returned %v, want a non-conflict error", err)
+		}
+	})
+}
+
+func TestStoreUpsertPost(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		user := createTestUser(t, s.db, "alice")
+		feed := createTestFeed(t, s.db, user, "Feed", "https://example.com/feed")
+		published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		created := createTestPost(t, s.db, feed, "post", "first", published)
+
+		params := database.UpsertPostParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+			Title:       created.Title,
+			Url:         created.Url,
+			PublishedAt: sql.NullTime{Time: published.Add(time.Hour), Valid: true},
+			FeedID:      feed.ID,
+			Guid:        created.Guid,
+			Content:     created.Content,
+		}
+		if post, err := s.db.UpsertPost(ctx, params); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("UpsertPost of an unchanged post returned %+v, %v, want no row", post, err)
+		}
+
+		params.Content = sql.NullString{String: "second", Valid: true}
+		updated, err := s.db.UpsertPost(ctx, params)
+		if err != nil {
+			t.Fatalf("UpsertPost of a changed post returned error: %v", err)
+		}
+		if updated.ID != created.ID || updated.Content.String != "second" {
+			t.Errorf("UpsertPost of a changed post returned %s %q, want %s %q", updated.ID, updated.Content.String, created.ID, "second")
+		}
+		if !updated.PublishedAt.Time.Equal(published) {
+			t.Errorf("UpsertPost changed the publication date to %v, want %v kept", updated.PublishedAt.Time, published)
+		}
+
+		posts, err := s.db.ListPostsForUser(ctx, database.ListPostsForUserParams{UserID: user.ID, Sort: database.SortPublished, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 1 {
+			t.Errorf("feed has %d posts after upserting the same GUID, want 1", len(posts))
+		}
+	})
+}
+
+func TestStoreListPosts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		alice := createTestUser(t, s.db, "alice")
+		bob := createTestUser(t, s.db, "bob")
+		feedA := createTestFeed(t, s.db, alice, "A", "https://example.com/a")
+		feedB := createTestFeed(t, s.db, alice, "B", "https://example.com/b")
+		feedC := createTestFeed(t, s.db, bob, "C", "https://example.com/c")
+
+		day := func(n int) time.Time { return time.Date(2024, 3, n, 12, 0, 0, 0, time.UTC) }
+		createTestPost(t, s.db, feedA, "a1", "", day(1))
+		a2 := createTestPost(t, s.db, feedA, "a2", "", day(3))
+		createTestPost(t, s.db, feedA, "a3", "", time.Time{})
+		createTestPost(t, s.db, feedB, "b1", "", day(2))
+		createTestPost(t, s.db, feedC, "c1", "", day(2))
+
+		if _, err := s.db.MarkPostRead(ctx, database.MarkPostReadParams{ReadAt: time.Now().UTC(), UserID: alice.ID, PostID: a2.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name string
+			arg  database.ListPostsForUserParams
+			want []string
+		}{
+			{"published", database.ListPostsForUserParams{}, []string{"a2", "b1", "a1", "a3"}},
+			{"reverse", database.ListPostsForUserParams{Reverse: true}, []string{"a1", "b1", "a2", "a3"}},
+			{"by feed", database.ListPostsForUserParams{Sort: database.SortFeed}, []string{"a2", "a1", "a3", "b1"}},
+			{"feed name", database.ListPostsForUserParams{Feed: "B"}, []string{"b1"}},
+			{"feed url", database.ListPostsForUserParams{Feed: feedA.Url}, []string{"a2", "a1", "a3"}},
+			{"unfollowed feed", database.ListPostsForUserParams{Feed: "C"}, nil},
+			{"since", database.ListPostsForUserParams{Since: day(2)}, []string{"a2", "b1"}},
+			{"until", database.ListPostsForUserParams{Until: day(2)}, []string{"a1"}},
+			{"since and until", database.ListPostsForUserParams{Since: day(1), Until: day(3)}, []string{"b1", "a1"}},
+			{"unread", database.ListPostsForUserParams{UnreadOnly: true}, []string{"b1", "a1", "a3"}},
+			{"first page", database.ListPostsForUserParams{Limit: 2}, []string{"a2", "b1"}},
+			{"second page", database.ListPostsForUserParams{Limit: 2, Offset: 2}, []string{"a1", "a3"}},
+			{"past the end", database.ListPostsForUserParams{Limit: 2, Offset: 4}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.arg.UserID = alice.ID
+				if tt.arg.Sort == "" {
+					tt.arg.Sort = database.SortPublished
+				}
+				if tt.arg.Limit == 0 {
+					tt.arg.Limit = 10
+				}
+				posts, err := s.db.ListPostsForUser(ctx, tt.arg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, post := range posts {
+					got = append(got, post.Title)
+					if read := post.ID == a2.ID; post.ReadAt.Valid != read {
+						t.Errorf("post %s has read_at %v, want read %t", post.Title, post.ReadAt, read)
+					}
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ListPostsForUser = %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		posts, err := s.db.ListPostsForUser(ctx, database.ListPostsForUserParams{UserID: bob.ID, Sort: database.SortPublished, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 1 || posts[0].Title != "c1" || posts[0].FeedName != "C" {
+			t.Errorf("ListPostsForUser for bob = %+v, want c1 of feed C", posts)
+		}
+		if _, err := s.db.ListPostsForUser(ctx, database.ListPostsForUserParams{UserID: alice.ID, Sort: "title", Limit: 10}); err == nil {
+			t.Error("ListPostsForUser accepted an unknown sort")
+		}
+	})
+}
+
+func TestStoreStarPost(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		alice := createTestUser(t, s.db, "alice")
+		bob := createTestUser(t, s.db, "bob")
+		feed := createTestFeed(t, s.db, alice, "A", "https://example.com/a")
+		post := createTestPost(t, s.db, feed, "post", "", time.Now().UTC())
+
+		star := func(user database.User) int64 {
+			t.Helper()
+			starred, err := s.db.StarPost(ctx, database.StarPostParams{StarredAt: time.Now().UTC(), UserID: user.ID, PostID: post.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return starred
+		}
+		if got := star(alice); got != 1 {
+			t.Errorf("StarPost of a followed post affected %d rows, want 1", got)
+		}
+		if got := star(alice); got != 1 {
+			t.Errorf("StarPost of a starred post affected %d rows, want 1", got)
+		}
+
+		// Bob doesn't follow the feed of the post.
+		if got := star(bob); got != 0 {
+			t.Errorf("StarPost of a post from an unfollowed feed affected %d rows, want 0", got)
+		}
+		err := handlerStarPost(s, command{Name: "star", Args: []string{post.ID.String()}}, bob)
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("star of a post from an unfollowed feed returned %v, want post not found", err)
+		}
+		starred, err := s.db.GetStarredPostsForUser(ctx, bob.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(starred) != 0 {
+			t.Errorf("bob has %d starred posts, want none", len(starred))
+		}
+	})
+}
+
+func TestStoreSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		alice := createTestUser(t, s.db, "alice")
+		bob := createTestUser(t, s.db, "bob")
+		feedA := createTestFeed(t, s.db, alice, "A", "https://example.com/a")
+		feedB := createTestFeed(t, s.db, alice, "B", "https://example.com/b")
+		feedC := createTestFeed(t, s.db, bob, "C", "https://example.com/c")
+
+		day := func(n int) time.Time { return time.Date(2024, 3, n, 12, 0, 0, 0, time.UTC) }
+		createTestPost(t, s.db, feedA, "Gopher news", "What happened this week", day(1))
+		createTestPost(t, s.db, feedB, "Weekly digest", "A gopher wrote a compiler", day(3))
+		createTestPost(t, s.db, feedB, "Crab news", "The gopher and the rust crab", day(2))
+		createTestPost(t, s.db, feedA, "Unrelated", "Nothing to see", day(4))
+		createTestPost(t, s.db, feedC, "Gopher gossip", "Not followed by alice", day(5))
+
+		tests := []struct {
+			name string
+			arg  database.SearchPostsForUserParams
+			want []string
+		}{
+			// Matches in the title rank above matches in the content.
+			{"word", database.SearchPostsForUserParams{Query: "gopher"}, []string{"Gopher news", "Weekly digest", "Crab news"}},
+			{"excluded word", database.SearchPostsForUserParams{Query: "gopher -rust"}, []string{"Gopher news", "Weekly digest"}},
+			{"phrase", database.SearchPostsForUserParams{Query: `"rust crab"`}, []string{"Crab news"}},
+			{"feed", database.SearchPostsForUserParams{Query: "gopher", Feed: sql.NullString{String: "B", Valid: true}}, []string{"Weekly digest", "Crab news"}},
+			{"since", database.SearchPostsForUserParams{Query: "gopher", Since: sql.NullTime{Time: day(2), Valid: true}}, []string{"Weekly digest", "Crab news"}},
+			{"limit", database.SearchPostsForUserParams{Query: "gopher", PostLimit: 1}, []string{"Gopher news"}},
+			{"no match", database.SearchPostsForUserParams{Query: "python"}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.arg.UserID = alice.ID
+				if tt.arg.PostLimit == 0 {
+					tt.arg.PostLimit = 10
+				}
+				rows, err := s.db.SearchPostsForUser(ctx, tt.arg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, row := range rows {
+					got = append(got, row.Title)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("SearchPostsForUser(%q) = %v, want %v", tt.arg.Query, got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestStoreLeases(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *state) {
+		ctx := context.Background()
+		user := createTestUser(t, s.db, "alice")
+		feed1 := createTestFeed(t, s.db, user, "One", "https://example.com/1")
+		feed2 := createTestFeed(t, s.db, user, "Two", "https://example.com/2")
+		now := time.Now().UTC().Truncate(time.Second)
+
+		// next returns the names of the feeds owner leases at, sorted since
+		// the order of the rows an UPDATE returns isn't defined.
+		next := func(owner string, at time.Time) []string {
+			t.Helper()
+			feeds, err := s.db.GetNextFeedsToFetch(ctx, database.GetNextFeedsToFetchParams{
+				Now:          at,
+				LeaseOwner:   owner,
+				LeaseSeconds: 60,
+				FeedLimit:    10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, feed := range feeds {
+				if feed.LeaseOwner.String != owner {
+					t.Errorf("feed %s leased to %q, want %q", feed.Name, feed.LeaseOwner.String, owner)
+				}
+				names = append(names, feed.Name)
+			}
+			sort.Strings(names)
+			return names
+		}
+		overdue := func(at time.Time) int64 {
+			t.Helper()
+			count, err := s.db.CountOverdueFeeds(ctx, at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return count
+		}
+
+		if got := overdue(now); got != 2 {
+			t.Errorf("CountOverdueFeeds = %d before any fetch, want 2", got)
+		}
+		if got := next("a", now); len(got) != 2 {
+			t.Fatalf("aggregator a leased %d feeds, want 2", len(got))
+		}
+		if got := next("b", now); len(got) != 0 {
+			t.Errorf("aggregator b leased %d feeds leased to a, want none", len(got))
+		}
+		if got := overdue(now); got != 0 {
+			t.Errorf("CountOverdueFeeds = %d while every feed is leased, want 0", got)
+		}
+
+		claim := database.ClaimFeedParams{Now: now, LeaseOwner: "b", LeaseSeconds: 60, ID: feed1.ID}
+		if _, err := s.db.ClaimFeed(ctx, claim); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("ClaimFeed of a feed leased to another aggregator returned %v, want no row", err)
+		}
+		claim.LeaseOwner = "a"
+		if _, err := s.db.ClaimFeed(ctx, claim); err != nil {
+			t.Errorf("ClaimFeed of a feed leased to the caller returned %v", err)
+		}
+
+		// Only the lease owner can release it.
+		if err := s.db.ReleaseFeedLease(ctx, database.ReleaseFeedLeaseParams{ID: feed1.ID, LeaseOwner: sql.NullString{String: "b", Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := next("b", now); len(got) != 0 {
+			t.Errorf("aggregator b leased %d feeds after releasing a lease it didn't hold, want none", len(got))
+		}
+		if err := s.db.ReleaseFeedLease(ctx, database.ReleaseFeedLeaseParams{ID: feed1.ID, LeaseOwner: sql.NullString{String: "a", Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := next("b", now); !reflect.DeepEqual(got, []string{"One"}) {
+			t.Errorf("aggregator b leased %v after a released feed 1, want %v", got, []string{"One"})
+		}
+
+		// Scheduling the next fetch releases the lease; the feed waits until
+		// it is due while the expired lease on feed 2 lets it be fetched again.
+		err := s.db.UpdateFeedNextFetch(ctx, database.UpdateFeedNextFetchParams{
+			ID:          feed1.ID,
+			NextFetchAt: sql.NullTime{Time: now.Add(time.Hour), Valid: true},
+			UpdatedAt:   now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		later := now.Add(2 * time.Minute)
+		if got := overdue(later); got != 1 {
+			t.Errorf("CountOverdueFeeds = %d after the lease on feed 2 expired, want 1", got)
+		}
+		if got := next("c", later); !reflect.DeepEqual(got, []string{"Two"}) {
+			t.Errorf("aggregator c leased %v after the lease on feed 2 expired, want %v", got, []string{"Two"})
+		}
+		if got := next("c", now.Add(2*time.Hour)); !reflect.DeepEqual(got, []string{"One", "Two"}) {
+			t.Errorf("aggregator c leased %v once feed 1 was due, want %v", got, []string{"One", "Two"})
+		}
+
+		// Disabled feeds are never leased.
+		if err := s.db.DisableFeed(ctx, database.DisableFeedParams{Now: now, ID: feed2.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if got := next("d", now.Add(3*time.Hour)); !reflect.DeepEqual(got, []string{"One"}) {
+			t.Errorf("aggregator d leased %v with feed 2 disabled, want %v", got, []string{"One"})
+		}
+	})
+}